@@ -10,6 +10,7 @@ import (
 
 var cfg = config.Config{
 	GoModules:     []string{"."},
+	GoBinaries:    []string{"cmd/sage-ci"},
 	SkipWorkflows: []string{"sage-ci-sync"},
 }
 
@@ -36,3 +37,10 @@ func All(ctx context.Context) error {
 func UpdateSageCi(ctx context.Context) error {
 	return targets.UpdateSageCi(ctx, cfg)
 }
+
+// ToolsLock refreshes the tool checksums in tools/internal/sgdownload/tools.lock.
+func ToolsLock(ctx context.Context) error {
+	cmd := sg.Command(ctx, "go", "run", "./tools/internal/lockgen")
+	cmd.Dir = sg.FromGitRoot()
+	return cmd.Run()
+}
@@ -33,6 +33,26 @@ func GoVulncheck(ctx context.Context) error {
 	return targets.GoVulncheck(ctx, cfg)
 }
 
+// GoBench runs the goBench target for configured Go modules.
+func GoBench(ctx context.Context) error {
+	return targets.GoBench(ctx, cfg)
+}
+
+// GoGenerate runs the goGenerate target for configured Go modules.
+func GoGenerate(ctx context.Context) error {
+	return targets.GoGenerate(ctx, cfg)
+}
+
+// GoBuild runs the goBuild target for configured Go modules.
+func GoBuild(ctx context.Context) error {
+	return targets.GoBuild(ctx, cfg)
+}
+
+// GoCrossBuild runs the goCrossBuild target for configured Go modules.
+func GoCrossBuild(ctx context.Context) error {
+	return targets.GoCrossBuild(ctx, cfg)
+}
+
 // GenerateWorkflows regenerates CI workflows for the configured platform.
 func GenerateWorkflows(ctx context.Context) error {
 	return targets.GenerateWorkflows(cfg)
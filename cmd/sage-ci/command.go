@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// commandsFile is added to the .sage package to build the commands binary. Package variables, such as
+// the sagefile's cfg, are initialized before its init function runs, which runs the command and exits
+// before the sagefile's main. The file is named to sort last, so the other init functions run first.
+const commandsFile = `// Code generated by sage-ci. DO NOT EDIT.
+
+package main
+
+import (
+	"os"
+
+	%q
+)
+
+func init() {
+	os.Exit(targets.RunCommand(cfg, os.Args[1:]))
+}
+`
+
+// commandsPath returns the path of the commands binary built from the .sage directory in root.
+func commandsPath(root string) string {
+	return filepath.Join(root, ".sage", "bin", "sage-ci-commands")
+}
+
+// buildCommands builds the .sage package with commandsFile into the commands binary. The file is added
+// through a build overlay, so neither the .sage sources nor the Makefile get targets for the commands.
+func buildCommands(root string) error {
+	sageDir := filepath.Join(root, ".sage")
+	importPath, err := targetsImportPath(sageDir)
+	if err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp("", "sage-ci-commands-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	source := filepath.Join(tmpDir, "commands.go")
+	if err := os.WriteFile(source, fmt.Appendf(nil, commandsFile, importPath), 0o644); err != nil {
+		return err
+	}
+	overlay, err := json.Marshal(map[string]map[string]string{
+		"Replace": {filepath.Join(sageDir, "zz_sage_ci_commands.go"): source},
+	})
+	if err != nil {
+		return err
+	}
+	overlayPath := filepath.Join(tmpDir, "overlay.json")
+	if err := os.WriteFile(overlayPath, overlay, 0o644); err != nil {
+		return err
+	}
+	cmd := exec.Command("go", "build", "-overlay", overlayPath, "-o", commandsPath(root), ".")
+	cmd.Dir = sageDir
+	cmd.Env = append(os.Environ(), "GOWORK=off")
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf(
+			"build sage-ci commands in .sage, sage-ci in .sage/go.mod may be too old, run make update-sage-ci: %w", err,
+		)
+	}
+	return nil
+}
+
+// targetsImportPath returns the import path of the targets package the sources in sageDir use:
+// the copy in .sage/targets after sage-ci eject -inline, or the sage-ci module's.
+func targetsImportPath(sageDir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(sageDir, "targets")); err != nil {
+		return "github.com/fredrikaverpil/sage-ci/targets", nil
+	}
+	cmd := exec.Command("go", "list", "-m")
+	cmd.Dir = sageDir
+	cmd.Env = append(os.Environ(), "GOWORK=off")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("read the .sage module path: %w", err)
+	}
+	return strings.TrimSpace(string(output)) + "/targets", nil
+}
+
+// runCommand runs the sage-ci command named name, such as "ExplainConfig", with args in root,
+// building the sagefile and the commands binary first if they are missing or out of date.
+func runCommand(root, name string, args ...string) error {
+	// Building the sagefile also tidies .sage/go.mod, which the commands binary is built with.
+	if err := ensureSagefile(root, false); err != nil {
+		return err
+	}
+	upToDate, err := binaryUpToDate(root, commandsPath(root))
+	if err != nil {
+		return err
+	}
+	if !upToDate {
+		if err := buildCommands(root); err != nil {
+			return err
+		}
+	}
+	cmd := exec.Command(commandsPath(root), append([]string{name}, args...)...)
+	cmd.Dir = root
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return targetError{target: name, exitCode: exitErr.ExitCode()}
+		}
+		return fmt.Errorf("run %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+// commands lists the sage-ci commands offered by shell completion.
+const commands = "init run list config doctor upgrade eject tools uninstall schema completion"
+
+const bashCompletion = `# bash completion for sage-ci, load with: source <(sage-ci completion bash)
+_sage_ci() {
+	local cur=${COMP_WORDS[COMP_CWORD]}
+	if [ "$COMP_CWORD" -eq 1 ]; then
+		COMPREPLY=($(compgen -W "` + commands + `" -- "$cur"))
+	elif [ "${COMP_WORDS[1]}" = run ] && [[ "$cur" != -* ]]; then
+		COMPREPLY=($(compgen -W "$(sage-ci run -list 2>/dev/null)" -- "$cur"))
+	fi
+}
+complete -F _sage_ci sage-ci
+`
+
+const zshCompletion = `#compdef sage-ci
+# zsh completion for sage-ci, load with: source <(sage-ci completion zsh)
+_sage_ci() {
+	if (( CURRENT == 2 )); then
+		compadd -- ` + commands + `
+	elif [[ $words[2] == run ]]; then
+		compadd -- ${(f)"$(sage-ci run -list 2>/dev/null)"}
+	fi
+}
+compdef _sage_ci sage-ci
+`
+
+const fishCompletion = `# fish completion for sage-ci, load with: sage-ci completion fish | source
+complete -c sage-ci -f -n __fish_use_subcommand -a "` + commands + `"
+complete -c sage-ci -f -n "__fish_seen_subcommand_from run" -a "(sage-ci run -list 2>/dev/null)"
+`
+
+// runCompletion prints the completion script for shell.
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected a shell: bash, zsh or fish")
+	}
+	switch args[0] {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	case "fish":
+		fmt.Print(fishCompletion)
+	default:
+		return fmt.Errorf("unknown shell %q, expected bash, zsh or fish", args[0])
+	}
+	return nil
+}
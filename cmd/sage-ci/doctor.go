@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+
+	"github.com/fredrikaverpil/sage-ci/targets"
+)
+
+// runDoctor checks what the sagefile binary needs before running the Doctor command,
+// which checks the toolchain, dependencies, generated files and tool cache using the configuration.
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	root, err := projectRoot()
+	if err != nil {
+		return err
+	}
+
+	checks := []targets.Check{{Name: "go", Status: targets.CheckPass, Message: "found"}}
+	if _, err := exec.LookPath("go"); err != nil {
+		checks[0] = targets.Check{
+			Name: "go", Status: targets.CheckFail, Message: "go not found in PATH",
+			Hint: "install Go from https://go.dev/dl",
+		}
+		if err := targets.WriteChecks(os.Stdout, checks); err != nil {
+			return err
+		}
+		return errors.New("go is required to build the sagefile")
+	}
+	sagefile := targets.Check{Name: "sagefile", Status: targets.CheckPass, Message: "up to date"}
+	upToDate, err := sagefileUpToDate(root)
+	if err != nil {
+		return err
+	}
+	if !upToDate {
+		sagefile.Status, sagefile.Message = targets.CheckWarn, "missing or older than the .sage sources, rebuilding"
+		sagefile.Hint = "run make sage, which sage-ci run does automatically"
+	}
+	checks = append(checks, sagefile)
+	if err := targets.WriteChecks(os.Stdout, checks); err != nil {
+		return err
+	}
+
+	return runCommand(root, "Doctor")
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"github.com/fredrikaverpil/sage-ci/targets"
+)
+
+//go:embed templates/sagefile.go.tmpl
+var sagefileTemplate string
+
+//go:embed templates/sage-ci.yaml.tmpl
+var configYAMLTemplate string
+
+//go:embed templates/sage-ci.toml.tmpl
+var configTOMLTemplate string
+
+//go:embed templates/go.mod.tmpl
+var gomodContent string
+
+// listFlag is a comma-separated list flag. It is nil unless set.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = splitList(value)
+	return nil
+}
+
+// splitList splits a comma-separated list, ignoring empty items.
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func runInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	configStyle := fs.String("config", "go", "where to keep the configuration: go, yaml or toml")
+	yes := fs.Bool("yes", false, "accept the detected configuration without prompting")
+	var platforms, goVersions, pythonVersions, osVersions listFlag
+	fs.Var(&platforms, "platforms", "comma-separated workflow platforms (default: detected from the origin remote)")
+	fs.Var(&goVersions, "go-versions", "comma-separated Go versions of the CI test matrix")
+	fs.Var(&pythonVersions, "python-versions", "comma-separated Python versions of the CI test matrix")
+	fs.Var(&osVersions, "os-versions", "comma-separated runner images of the CI test matrix")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var configFile, configTemplate string
+	switch *configStyle {
+	case "go":
+	case "yaml":
+		configFile, configTemplate = "sage-ci.yaml", configYAMLTemplate
+	case "toml":
+		configFile, configTemplate = "sage-ci.toml", configTOMLTemplate
+	default:
+		return fmt.Errorf("unknown config style %q, expected go, yaml or toml", *configStyle)
+	}
+
+	// Check if sagefile.go already exists.
+	if _, err := os.Stat(".sage/sagefile.go"); err == nil {
+		return errors.New(".sage/sagefile.go already exists")
+	}
+
+	cfg, err := config.Detect(".")
+	if err != nil {
+		return err
+	}
+	cfg.Platforms = detectPlatforms()
+	cfg.DefaultBranch = targets.DetectDefaultBranch(".")
+	fmt.Printf("Detected Go modules: %s\n", describeList(cfg.GoModules))
+	fmt.Printf("Detected Python modules: %s\n", describeList(cfg.PythonModules))
+	fmt.Printf("Detected Lua modules: %s\n", describeList(cfg.LuaModules))
+	fmt.Printf("Detected platforms: %s\n", describeList(cfg.Platforms))
+	fmt.Printf("Detected default branch: %s\n", cmp.Or(cfg.DefaultBranch, "none, CI runs on main"))
+
+	p := prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout, enabled: !*yes && isTerminal(os.Stdin)}
+	cfg.GoModules = p.list("Go modules", cfg.GoModules)
+	cfg.PythonModules = p.list("Python modules", cfg.PythonModules)
+	cfg.LuaModules = p.list("Lua modules", cfg.LuaModules)
+	defaults := config.Config{}.WithDefaults()
+	if platforms == nil {
+		platforms = p.list("Platforms", toStrings(cfg.Platforms))
+	}
+	cfg.Platforms = nil
+	for _, platform := range platforms {
+		cfg.Platforms = append(cfg.Platforms, config.Platform(platform))
+	}
+	if goVersions == nil && len(cfg.GoModules) > 0 {
+		goVersions = p.optionalList("Go versions", defaults.GoVersions)
+	}
+	if pythonVersions == nil && len(cfg.PythonModules) > 0 {
+		pythonVersions = p.optionalList("Python versions", defaults.PythonVersions)
+	}
+	if osVersions == nil && (len(cfg.GoModules) > 0 || len(cfg.PythonModules) > 0) {
+		osVersions = p.optionalList("OS versions", defaults.OSVersions)
+	}
+	cfg.GoVersions, cfg.PythonVersions, cfg.OSVersions = goVersions, pythonVersions, osVersions
+
+	sagefileContent, err := renderSagefile(configFile, cfg)
+	if err != nil {
+		return err
+	}
+	var configContent []byte
+	if configFile != "" {
+		if configContent, err = renderConfigFile(configFile, configTemplate, cfg); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(".sage", 0o755); err != nil {
+		return fmt.Errorf("create .sage directory: %w", err)
+	}
+
+	if err := os.WriteFile(".sage/go.mod", []byte(gomodContent), 0o644); err != nil {
+		return fmt.Errorf("write .sage/go.mod: %w", err)
+	}
+
+	if err := os.WriteFile(".sage/sagefile.go", sagefileContent, 0o644); err != nil {
+		return fmt.Errorf("write .sage/sagefile.go: %w", err)
+	}
+
+	if configFile != "" {
+		if err := os.WriteFile(".sage/"+configFile, configContent, 0o644); err != nil {
+			return fmt.Errorf("write .sage/%s: %w", configFile, err)
+		}
+		fmt.Printf("Initialized .sage/go.mod, .sage/sagefile.go and .sage/%s\n", configFile)
+	} else {
+		fmt.Println("Initialized .sage/go.mod and .sage/sagefile.go")
+	}
+
+	fmt.Println("Running go get in .sage/...")
+	for _, pkg := range []string{
+		"github.com/fredrikaverpil/sage-ci@latest",
+		"go.einride.tech/sage@latest",
+	} {
+		getCmd := exec.Command("go", "get", pkg)
+		getCmd.Dir = ".sage"
+		getCmd.Stdout = os.Stdout
+		getCmd.Stderr = os.Stderr
+		if err := getCmd.Run(); err != nil {
+			return fmt.Errorf("go get %s: %w", pkg, err)
+		}
+	}
+
+	// Building the sagefile runs go mod tidy and generates the Makefile,
+	// UpdateSageCi then generates targets.gen.go and the workflows.
+	fmt.Println("Generating Makefile, targets and workflows...")
+	if err := buildSagefile("."); err != nil {
+		return err
+	}
+	if err := runSagefile(".", "UpdateSageCi", false); err != nil {
+		return err
+	}
+
+	fmt.Println("Done. Run all targets with:")
+	fmt.Println("  make  # or: sage-ci run all")
+	if configFile != "" {
+		fmt.Printf("After editing .sage/%s, regenerate targets and workflows with:\n", configFile)
+	} else {
+		fmt.Println("After editing .sage/sagefile.go, regenerate targets and workflows with:")
+	}
+	fmt.Println("  make update-sage-ci  # or: sage-ci run update-sage-ci")
+	return nil
+}
+
+// detectPlatforms returns the platform hosting the origin remote.
+// Only GitHub workflows are implemented, so other platforms fall back to GitHub.
+func detectPlatforms() []config.Platform {
+	output, err := exec.Command("git", "remote", "get-url", "origin").Output()
+	if err != nil {
+		return []config.Platform{config.PlatformGitHub}
+	}
+	platform, ok := config.PlatformForRemote(strings.TrimSpace(string(output)))
+	if !ok {
+		return []config.Platform{config.PlatformGitHub}
+	}
+	if platform != config.PlatformGitHub {
+		fmt.Printf("Note: %s workflows are not implemented yet, generating GitHub Actions workflows\n", platform)
+		return []config.Platform{config.PlatformGitHub}
+	}
+	return []config.Platform{platform}
+}
+
+// prompter asks for configuration values, if enabled.
+type prompter struct {
+	in      *bufio.Reader
+	out     io.Writer
+	enabled bool
+}
+
+// list asks for a comma-separated list, keeping value on empty input and clearing it on "none".
+func (p prompter) list(question string, value []string) []string {
+	if !p.enabled {
+		return value
+	}
+	fmt.Fprintf(p.out, "%s [%s]: ", question, describeList(value))
+	answer, _ := p.in.ReadString('\n')
+	switch answer = strings.TrimSpace(answer); answer {
+	case "":
+		return value
+	case "none":
+		return []string{}
+	}
+	return splitList(answer)
+}
+
+// optionalList asks for a comma-separated list, returning nil to keep the defaults.
+func (p prompter) optionalList(question string, defaults []string) []string {
+	if !p.enabled {
+		return nil
+	}
+	fmt.Fprintf(p.out, "%s [%s]: ", question, strings.Join(defaults, ", "))
+	answer, _ := p.in.ReadString('\n')
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return nil
+	}
+	return splitList(answer)
+}
+
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// describeList returns the list as "a, b", or "none".
+func describeList[T ~string](list []T) string {
+	if len(list) == 0 {
+		return "none"
+	}
+	return strings.Join(toStrings(list), ", ")
+}
+
+func toStrings[T ~string](list []T) []string {
+	strs := make([]string, 0, len(list))
+	for _, s := range list {
+		strs = append(strs, string(s))
+	}
+	return strs
+}
+
+// templateFuncs are the functions available to the init templates.
+var templateFuncs = template.FuncMap{
+	// goStrings renders a Go []string literal.
+	"goStrings": func(list []string) string {
+		quoted := make([]string, 0, len(list))
+		for _, s := range list {
+			quoted = append(quoted, strconv.Quote(s))
+		}
+		return "[]string{" + strings.Join(quoted, ", ") + "}"
+	},
+	// goPlatforms renders a Go []config.Platform literal using the Platform constants.
+	"goPlatforms": func(platforms []config.Platform) string {
+		names := map[config.Platform]string{
+			config.PlatformGitHub:   "config.PlatformGitHub",
+			config.PlatformGitLab:   "config.PlatformGitLab",
+			config.PlatformCodeberg: "config.PlatformCodeberg",
+		}
+		var items []string
+		for _, platform := range platforms {
+			if name, ok := names[platform]; ok {
+				items = append(items, name)
+			} else {
+				items = append(items, strconv.Quote(string(platform)))
+			}
+		}
+		return "[]config.Platform{" + strings.Join(items, ", ") + "}"
+	},
+	// list renders a YAML or TOML array, which share JSON's syntax for lists of strings.
+	"list": func(list any) (string, error) {
+		v := reflect.ValueOf(list)
+		items := make([]string, 0, v.Len())
+		for i := range v.Len() {
+			b, err := json.Marshal(v.Index(i).Interface())
+			if err != nil {
+				return "", fmt.Errorf("marshal list item: %w", err)
+			}
+			items = append(items, string(b))
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	},
+}
+
+// initData is the data of the init templates.
+type initData struct {
+	// ConfigFile is the declarative configuration file, if the configuration isn't defined in Go.
+	ConfigFile string
+	// Config holds the detected and prompted configuration values.
+	Config config.Config
+}
+
+// renderSagefile renders the sagefile template with the configuration in cfg.
+// If configFile is set, the configuration is loaded from that file instead of being defined in Go.
+func renderSagefile(configFile string, cfg config.Config) ([]byte, error) {
+	tmpl, err := template.New("sagefile").Funcs(templateFuncs).Parse(sagefileTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse sagefile template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, initData{ConfigFile: configFile, Config: cfg}); err != nil {
+		return nil, fmt.Errorf("execute sagefile template: %w", err)
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format sagefile: %w", err)
+	}
+	return formatted, nil
+}
+
+// renderConfigFile renders the declarative configuration file template with the configuration in cfg.
+func renderConfigFile(configFile, configTemplate string, cfg config.Config) ([]byte, error) {
+	tmpl, err := template.New(configFile).Funcs(templateFuncs).Parse(configTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s template: %w", configFile, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, initData{ConfigFile: configFile, Config: cfg}); err != nil {
+		return nil, fmt.Errorf("execute %s template: %w", configFile, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -1,19 +1,18 @@
 package main
 
 import (
-	_ "embed"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"os/exec"
-)
-
-//go:embed templates/sagefile.go.tmpl
-var sagefileContent string
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 
-//go:embed templates/go.mod.tmpl
-var gomodContent string
+	"github.com/fredrikaverpil/sage-ci/config"
+	"github.com/fredrikaverpil/sage-ci/targets"
+)
 
 func main() {
 	if len(os.Args) < 2 {
@@ -24,71 +23,266 @@ func main() {
 	switch os.Args[1] {
 	case "init":
 		if err := runInit(os.Args[2:]); err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
+			exit(err)
+		}
+	case "run":
+		if err := runRun(os.Args[2:]); err != nil {
+			exit(err)
+		}
+	case "doctor":
+		if err := runDoctor(os.Args[2:]); err != nil {
+			exit(err)
+		}
+	case "upgrade":
+		if err := runUpgrade(os.Args[2:]); err != nil {
+			exit(err)
+		}
+	case "eject":
+		if err := runEject(os.Args[2:]); err != nil {
+			exit(err)
+		}
+	case "list":
+		if err := runList(os.Args[2:]); err != nil {
+			exit(err)
+		}
+	case "tools":
+		if err := runTools(os.Args[2:]); err != nil {
+			exit(err)
 		}
+	case "uninstall":
+		if err := runUninstall(os.Args[2:]); err != nil {
+			exit(err)
+		}
+	case "completion":
+		if err := runCompletion(os.Args[2:]); err != nil {
+			exit(err)
+		}
+	case "config":
+		if err := runConfig(os.Args[2:]); err != nil {
+			exit(err)
+		}
+	case "schema":
+		schema, err := config.Schema(targets.TargetNames())
+		if err != nil {
+			exit(err)
+		}
+		os.Stdout.Write(schema)
 	default:
 		usage()
 		os.Exit(1)
 	}
 }
 
+// exit exits with the exit code of a failed target, which already reported its failure,
+// or prints err and exits with 1.
+func exit(err error) {
+	var targetErr targetError
+	if errors.As(err, &targetErr) {
+		os.Exit(targetErr.exitCode)
+	}
+	fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	os.Exit(1)
+}
+
 func usage() {
 	fmt.Println(`Usage: sage-ci <command> [flags]
 
 Commands:
-  init    Bootstrap a new project with .sage/ directory`)
+  init    Bootstrap a new project with .sage/ directory, configured with the Go, Python
+          and Lua modules found in the working directory, and generate the Makefile,
+          targets and workflows
+          -config go|yaml|toml  where to keep the configuration (default: go)
+          -yes                  accept the detected configuration without prompting
+          -platforms, -go-versions, -python-versions, -os-versions  comma-separated
+                                values instead of prompting
+  run <target> [args...]  Run a sage target without make, e.g. go-test or GoTest.
+          The .sage sagefile binary is rebuilt when its sources change.
+          -rebuild  rebuild the sagefile binary first
+          -list     list the available targets
+  config explain  Print the effective configuration, the targets run per module and
+                  the workflows generated
+          -format table|json  output format (default: table)
+  list [targets|workflows|tools]  List the targets sage-ci provides (ecosystem, mutating
+          or read-only, modules they run for), the workflow templates (file, generated or
+          skipped) and the tools (pinned version, installed or not), default: all three
+          -format table|json  output format (default: table)
+  doctor  Check the Go toolchain, make, the sage and sage-ci versions in .sage/go.mod,
+          targets.gen.go, generated workflows, the tool cache and GitHub Actions
+          permissions, printing pass, warn or fail with a hint for each check
+  upgrade [version]  Upgrade sage-ci in .sage/go.mod to a version or constraint such as
+          v0.5, ~0.4.1 or latest (default: the configured sage-ci-version), showing the
+          changelog and changes to generated files first
+          -dry-run   only show the changelog and changes
+          -rollback  restore the version from before the last upgrade
+  eject   Render workflows and targets.gen.go one final time without their DO NOT EDIT
+          headers, and configure sage-ci to stop generating them
+          -workflows all|none|names  comma-separated workflows to eject (default: all)
+          -targets                   eject targets.gen.go to targets.go (default: true)
+          -inline                    also copy the targets package into .sage/targets
+  tools vendor  Download the release archives of the pinned tools into a directory, for
+          installing them without internet access by setting SAGE_CI_TOOLS_MIRROR to the
+          directory or to a URL serving it
+          -dir   directory to download into (default: .sage/mirror)
+          -os    GOOS to download for (default: the host OS)
+          -arch  GOARCH to download for (default: the host architecture)
+  uninstall  Remove .sage, the Makefiles generated by Sage and the generated sage-ci-*.yml
+          workflows after confirmation, keeping ejected workflows and custom .sage files
+          -all      also remove ejected workflows and custom .sage files
+          -dry-run  only show what would be removed
+          -yes      remove without asking for confirmation
+  schema  Print the JSON Schema for .sage/sage-ci.yaml and .sage/sage-ci.toml
+  completion bash|zsh|fish  Print a shell completion script`)
 }
 
-func runInit(args []string) error {
-	fs := flag.NewFlagSet("init", flag.ExitOnError)
+func runRun(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	rebuild := fs.Bool("rebuild", false, "rebuild the sagefile binary first")
+	list := fs.Bool("list", false, "list the available targets")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-
-	if err := os.MkdirAll(".sage", 0o755); err != nil {
-		return fmt.Errorf("create .sage directory: %w", err)
+	root, err := projectRoot()
+	if err != nil {
+		return err
 	}
-
-	// Check if sagefile.go already exists.
-	if _, err := os.Stat(".sage/sagefile.go"); err == nil {
-		return errors.New(".sage/sagefile.go already exists")
+	if *list {
+		if err := ensureSagefile(root, *rebuild); err != nil {
+			return err
+		}
+		targets, err := sagefileTargets(root)
+		if err != nil {
+			return err
+		}
+		for _, target := range targets {
+			fmt.Println(kebabCase(target))
+		}
+		return nil
 	}
+	if fs.NArg() == 0 {
+		usage()
+		return errors.New("expected a target to run")
+	}
+	return runSagefile(root, fs.Arg(0), *rebuild, fs.Args()[1:]...)
+}
 
-	if err := os.WriteFile(".sage/go.mod", []byte(gomodContent), 0o644); err != nil {
-		return fmt.Errorf("write .sage/go.mod: %w", err)
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "explain" {
+		usage()
+		return errors.New("expected a config subcommand: explain")
 	}
+	fs := flag.NewFlagSet("config explain", flag.ExitOnError)
+	outputFormat := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	switch *outputFormat {
+	case "table", "json":
+	default:
+		return fmt.Errorf("unknown format %q, expected table or json", *outputFormat)
+	}
+	root, err := projectRoot()
+	if err != nil {
+		return err
+	}
+	return runCommand(root, "ExplainConfig", *outputFormat)
+}
 
-	if err := os.WriteFile(".sage/sagefile.go", []byte(sagefileContent), 0o644); err != nil {
-		return fmt.Errorf("write .sage/sagefile.go: %w", err)
+func runUpgrade(args []string) error {
+	fs := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only show the changelog and changes to generated files")
+	rollback := fs.Bool("rollback", false, "restore the version from before the last upgrade")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 || (*rollback && (fs.NArg() > 0 || *dryRun)) {
+		usage()
+		return errors.New("expected at most one version, and no version or -dry-run with -rollback")
+	}
+	root, err := projectRoot()
+	if err != nil {
+		return err
 	}
+	switch {
+	case *rollback:
+		return runCommand(root, "RollbackSageCi")
+	case *dryRun:
+		return runCommand(root, "PreviewUpgradeSageCi", fs.Arg(0))
+	default:
+		return runCommand(root, "UpgradeSageCi", fs.Arg(0))
+	}
+}
 
-	fmt.Println("Initialized .sage/go.mod and .sage/sagefile.go")
+func runList(args []string) error {
+	view := "all"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		view, args = args[0], args[1:]
+	}
+	switch view {
+	case "all", "targets", "workflows", "tools":
+	default:
+		return fmt.Errorf("unknown list %q, expected targets, workflows or tools", view)
+	}
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	outputFormat := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *outputFormat {
+	case "table", "json":
+	default:
+		return fmt.Errorf("unknown format %q, expected table or json", *outputFormat)
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: sage-ci list [targets|workflows|tools] [-format table|json]")
+	}
+	root, err := projectRoot()
+	if err != nil {
+		return err
+	}
+	return runCommand(root, "ListSageCi", view, *outputFormat)
+}
 
-	fmt.Println("Running go get in .sage/...")
-	for _, pkg := range []string{
-		"github.com/fredrikaverpil/sage-ci@latest",
-		"go.einride.tech/sage@latest",
-	} {
-		getCmd := exec.Command("go", "get", pkg)
-		getCmd.Dir = ".sage"
-		getCmd.Stdout = os.Stdout
-		getCmd.Stderr = os.Stderr
-		if err := getCmd.Run(); err != nil {
-			return fmt.Errorf("go get %s: %w", pkg, err)
+func runTools(args []string) error {
+	if len(args) == 0 || args[0] != "vendor" {
+		usage()
+		return errors.New("expected a tools subcommand: vendor")
+	}
+	fs := flag.NewFlagSet("tools vendor", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory to download into (default: .sage/mirror)")
+	goos := fs.String("os", runtime.GOOS, "GOOS to download for")
+	goarch := fs.String("arch", runtime.GOARCH, "GOARCH to download for")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: sage-ci tools vendor [-dir dir] [-os goos] [-arch goarch]")
+	}
+	// The sagefile runs in the project root, so resolve dir from the working directory here.
+	if *dir != "" {
+		abs, err := filepath.Abs(*dir)
+		if err != nil {
+			return fmt.Errorf("resolve %s: %w", *dir, err)
 		}
+		*dir = abs
 	}
-
-	fmt.Println("Running go mod tidy in .sage/...")
-	tidyCmd := exec.Command("go", "mod", "tidy")
-	tidyCmd.Dir = ".sage"
-	tidyCmd.Stdout = os.Stdout
-	tidyCmd.Stderr = os.Stderr
-	if err := tidyCmd.Run(); err != nil {
-		return fmt.Errorf("go mod tidy: %w", err)
+	root, err := projectRoot()
+	if err != nil {
+		return err
 	}
+	return runCommand(root, "VendorTools", *dir, *goos, *goarch)
+}
 
-	fmt.Println("Edit .sage/sagefile.go to configure your project, then run:")
-	fmt.Println("  cd .sage && go run ./...")
-	return nil
+func runEject(args []string) error {
+	fs := flag.NewFlagSet("eject", flag.ExitOnError)
+	workflows := fs.String("workflows", "all", "comma-separated workflows to eject, all or none")
+	targetsFile := fs.Bool("targets", true, "eject targets.gen.go to targets.go")
+	inline := fs.Bool("inline", false, "also copy the targets package into .sage/targets")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	root, err := projectRoot()
+	if err != nil {
+		return err
+	}
+	return runCommand(root, "EjectSageCi", *workflows, strconv.FormatBool(*targetsFile), strconv.FormatBool(*inline))
 }
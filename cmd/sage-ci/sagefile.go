@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// projectRoot returns the root of the git repository containing the working directory.
+func projectRoot() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("find git repository root: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// sagefilePath returns the path of the sagefile binary built from the .sage directory in root.
+func sagefilePath(root string) string {
+	return filepath.Join(root, ".sage", "bin", "sagefile")
+}
+
+// buildSagefile builds the sagefile binary, like the $(sagefile) Makefile target.
+// Build output goes to stderr, so it does not mix with the output of targets.
+func buildSagefile(root string) error {
+	if _, err := os.Stat(filepath.Join(root, ".sage", "go.mod")); err != nil {
+		return fmt.Errorf("no .sage/go.mod found in %s, run sage-ci init first", root)
+	}
+	for _, args := range [][]string{{"mod", "tidy"}, {"run", "."}} {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = filepath.Join(root, ".sage")
+		cmd.Env = append(os.Environ(), "GOWORK=off")
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("go %s in .sage: %w", strings.Join(args, " "), err)
+		}
+	}
+	return nil
+}
+
+// sagefileUpToDate reports whether the sagefile binary is newer than the Go sources,
+// go.mod and go.sum in .sage. Configuration files are read at runtime and don't require a rebuild.
+func sagefileUpToDate(root string) (bool, error) {
+	return binaryUpToDate(root, sagefilePath(root))
+}
+
+// binaryUpToDate reports whether the binary at path, built from the .sage directory in root,
+// is newer than the Go sources, go.mod and go.sum in .sage.
+func binaryUpToDate(root, path string) (bool, error) {
+	binary, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("stat %s: %w", filepath.Base(path), err)
+	}
+	sageDir := filepath.Join(root, ".sage")
+	upToDate := true
+	err = filepath.WalkDir(sageDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Skip the directories sage writes binaries, tools and build output to.
+			switch rel, _ := filepath.Rel(sageDir, path); rel {
+			case "bin", "tools", "build":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".go" && ext != ".mod" && ext != ".sum" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(binary.ModTime()) {
+			upToDate = false
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("check .sage sources: %w", err)
+	}
+	return upToDate, nil
+}
+
+// ensureSagefile builds the sagefile binary if it is missing or out of date, or if rebuild is set.
+func ensureSagefile(root string, rebuild bool) error {
+	if !rebuild {
+		upToDate, err := sagefileUpToDate(root)
+		if err != nil {
+			return err
+		}
+		if upToDate {
+			return nil
+		}
+	}
+	return buildSagefile(root)
+}
+
+// sagefileTargets returns the targets of the sagefile binary, as listed when it is run without arguments.
+func sagefileTargets(root string) ([]string, error) {
+	output, err := exec.Command(sagefilePath(root)).Output()
+	if err != nil {
+		return nil, fmt.Errorf("list sagefile targets: %w", err)
+	}
+	var targets []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "\t") {
+			targets = append(targets, strings.TrimSpace(line))
+		}
+	}
+	return targets, scanner.Err()
+}
+
+// resolveTarget returns the target among targets named name,
+// given either as the target function name (GoTest) or as the Makefile target name (go-test).
+func resolveTarget(targets []string, name string) (string, bool) {
+	for _, target := range targets {
+		if strings.EqualFold(strings.ReplaceAll(name, "-", ""), target) {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// kebabCase converts a target function name such as "GoTest" to its Makefile target name "go-test".
+func kebabCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('-')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToLower(b.String())
+}
+
+// runSagefile builds the sagefile binary if needed and runs the target named name with args in root.
+func runSagefile(root, name string, rebuild bool, args ...string) error {
+	if err := ensureSagefile(root, rebuild); err != nil {
+		return err
+	}
+	targets, err := sagefileTargets(root)
+	if err != nil {
+		return err
+	}
+	target, ok := resolveTarget(targets, name)
+	if !ok {
+		return fmt.Errorf("target %s not found in .sage, run sage-ci run update-sage-ci to regenerate targets", name)
+	}
+	cmd := exec.Command(sagefilePath(root), append([]string{target}, args...)...)
+	cmd.Dir = root
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return targetError{target: target, exitCode: exitErr.ExitCode()}
+		}
+		return fmt.Errorf("run %s: %w", target, err)
+	}
+	return nil
+}
+
+// targetError reports a target which exited with a non-zero exit code.
+// The target has already logged why it failed.
+type targetError struct {
+	target   string
+	exitCode int
+}
+
+func (e targetError) Error() string {
+	return fmt.Sprintf("%s failed with exit code %d", e.target, e.exitCode)
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestResolveTarget(t *testing.T) {
+	targets := []string{"All", "GoTest", "UpdateSageCi", "PythonMypy"}
+	for _, tt := range []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"GoTest", "GoTest", true},
+		{"go-test", "GoTest", true},
+		{"gotest", "GoTest", true},
+		{"update-sage-ci", "UpdateSageCi", true},
+		{"UPDATE-SAGE-CI", "UpdateSageCi", true},
+		{"all", "All", true},
+		{"go-tests", "", false},
+		{"update-sage", "", false},
+		{"", "", false},
+	} {
+		got, ok := resolveTarget(targets, tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("resolveTarget(%q) = %q, %t, want %q, %t", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"All", "all"},
+		{"GoTest", "go-test"},
+		{"UpdateSageCi", "update-sage-ci"},
+		{"PythonMypy", "python-mypy"},
+		{"", ""},
+	} {
+		if got := kebabCase(tt.in); got != tt.want {
+			t.Errorf("kebabCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
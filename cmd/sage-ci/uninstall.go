@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fredrikaverpil/sage-ci/targets"
+)
+
+// runUninstall removes what sage-ci generated in the project: .sage, the Makefiles generated by Sage
+// and the generated sage-ci-*.yml workflows. Ejected workflows and unknown .sage files are kept unless
+// -all is given. This doesn't use the sagefile, so it works even if .sage no longer builds.
+func runUninstall(args []string) error {
+	fs := flag.NewFlagSet("uninstall", flag.ExitOnError)
+	all := fs.Bool("all", false, "also remove ejected workflows and custom files in .sage")
+	dryRun := fs.Bool("dry-run", false, "only show what would be removed")
+	yes := fs.Bool("yes", false, "remove without asking for confirmation")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	root, err := projectRoot()
+	if err != nil {
+		return err
+	}
+	artifacts, err := targets.FindArtifacts(root)
+	if err != nil {
+		return err
+	}
+	if len(artifacts) == 0 {
+		fmt.Println("no sage-ci files found")
+		return nil
+	}
+
+	var remove []targets.Artifact
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, artifact := range artifacts {
+		action := "remove"
+		if artifact.Custom && !*all {
+			action = "keep"
+		} else {
+			remove = append(remove, artifact)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", action, artifact.Path, artifact.Kind)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(remove) < len(artifacts) {
+		fmt.Println("\nkept files are maintained by hand, run sage-ci uninstall -all to remove them too")
+	}
+	if *dryRun || len(remove) == 0 {
+		return nil
+	}
+
+	if !*yes {
+		if !isTerminal(os.Stdin) {
+			return errors.New("not removing files without confirmation, run sage-ci uninstall -yes")
+		}
+		fmt.Printf("\nRemove %d files and directories? [y/N]: ", len(remove))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return errors.New("uninstall cancelled")
+		}
+	}
+	if err := targets.RemoveArtifacts(root, remove); err != nil {
+		return err
+	}
+	fmt.Printf("removed %d files and directories\n", len(remove))
+	return nil
+}
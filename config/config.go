@@ -1,6 +1,11 @@
 // Package config provides shared configuration for sage-ci.
 package config
 
+import (
+	"fmt"
+	"slices"
+)
+
 // Platform represents a CI/CD platform for workflow generation.
 type Platform string
 
@@ -13,38 +18,157 @@ const (
 	PlatformCodeberg Platform = "codeberg"
 )
 
+// PythonTypechecker represents a Python type checker.
+type PythonTypechecker string
+
+const (
+	// TypecheckerMypy runs mypy.
+	TypecheckerMypy PythonTypechecker = "mypy"
+	// TypecheckerPyright runs pyright.
+	TypecheckerPyright PythonTypechecker = "pyright"
+	// TypecheckerTy runs ty.
+	TypecheckerTy PythonTypechecker = "ty"
+)
+
+// Runner selects how generated workflows run targets.
+type Runner string
+
+const (
+	// RunnerMake runs targets through the Makefile generated by sage.
+	RunnerMake Runner = "make"
+	// RunnerSageCi runs targets with sage-ci run, which doesn't require make.
+	RunnerSageCi Runner = "sage-ci"
+)
+
 // Config configures sage-ci targets and workflow generation.
 type Config struct {
 	// Ecosystem modules - explicit paths.
 	// E.g. []string{".", "subdir/mylib"}
-	GoModules []string
+	GoModules []string `json:"go-modules" yaml:"go-modules" toml:"go-modules"`
 	// E.g. []string{"python", "tools/cli"}
-	PythonModules []string
+	PythonModules []string `json:"python-modules" yaml:"python-modules" toml:"python-modules"`
 	// E.g. []string{"lua/plugin"}
-	LuaModules []string
+	LuaModules []string `json:"lua-modules" yaml:"lua-modules" toml:"lua-modules"`
+	// E.g. []string{"infra/stacks/prod"}
+	TerraformModules []string `json:"terraform-modules" yaml:"terraform-modules" toml:"terraform-modules"`
+
+	// Documentation files - glob patterns relative to the repository root.
+	// Only files known to git (tracked or untracked but not ignored) are matched.
+	// E.g. []string{"*.md", "docs/**/*.md"}
+	MarkdownFiles []string `json:"markdown-files" yaml:"markdown-files" toml:"markdown-files"`
+	// E.g. []string{"**/*.yml", "**/*.yaml"}
+	YAMLFiles []string `json:"yaml-files" yaml:"yaml-files" toml:"yaml-files"`
+	// DocsExclude lists glob patterns excluded from MarkdownFiles and YAMLFiles.
+	// default: [".github/workflows/sage-ci-*.yml"]
+	DocsExclude []string `json:"docs-exclude" yaml:"docs-exclude" toml:"docs-exclude"`
+
+	// Lint files - glob patterns relative to the repository root.
+	// E.g. []string{"Dockerfile", "docker/**/Dockerfile"}
+	Dockerfiles []string `json:"dockerfiles" yaml:"dockerfiles" toml:"dockerfiles"`
+	// Generated sage-ci workflows are linted too.
+	// E.g. []string{".github/workflows/*.yml"}
+	ActionsWorkflows []string `json:"actions-workflows" yaml:"actions-workflows" toml:"actions-workflows"`
 
 	// Workflow platforms to generate for.
 	// Default: ["github"]
-	Platforms []Platform
+	Platforms []Platform `json:"platforms" yaml:"platforms" toml:"platforms"`
+	// How generated workflows run targets, e.g. "sage-ci" for runners without make.
+	// default: "make"
+	Runner Runner `json:"runner" yaml:"runner" toml:"runner"`
 
 	// Workflow selection (default: all enabled if empty).
-	// E.g. []string{"sage-ci-stale", "sage-ci-release"}
-	// You can also use a string, and if found, the workflow will be skipped.
-	SkipWorkflows []string
+	// Workflow names or patterns, see MatchPattern.
+	// E.g. []string{"sage-ci-stale", "sage-ci-*-ci"}
+	SkipWorkflows []string `json:"skip-workflows" yaml:"skip-workflows" toml:"skip-workflows"`
 
 	// SkipTargets lists sage target names to skip.
-	// Key: Target name (e.g. "GoTest").
-	// Value: List of modules to skip. Use "*" to skip all modules.
-	// E.g. SkipTargets{"GoLint": {"tools"}}
-	SkipTargets SkipTargets
+	// Key: Target name or pattern (e.g. "GoTest" or "Python*").
+	// Value: List of modules or module patterns to skip. Use "*" to skip all modules.
+	// E.g. SkipTargets{"GoLint": {"tools"}, "Python*": {"examples/**"}}
+	SkipTargets SkipTargets `json:"skip-targets" yaml:"skip-targets" toml:"skip-targets"`
+	// Don't generate .sage/targets.gen.go, e.g. after sage-ci eject moved its targets to .sage/targets.go.
+	// default: false
+	SkipTargetsFile bool `json:"skip-targets-file" yaml:"skip-targets-file" toml:"skip-targets-file"`
+
+	// Workflow triggers
+
+	// Branch CI runs on after merge, and releases are cut from. sage-ci init sets it to the branch
+	// origin/HEAD points at.
+	// default: CI runs on "main", and releases are cut from "main" or "master"
+	DefaultBranch string `json:"default-branch" yaml:"default-branch" toml:"default-branch"`
+	// Additional branches or branch patterns that run CI on push.
+	// E.g. []string{"release/*"}
+	PushBranches []string `json:"push-branches" yaml:"push-branches" toml:"push-branches"`
+	// Tag patterns that run CI on push.
+	// E.g. []string{"v*"}
+	PushTags []string `json:"push-tags" yaml:"push-tags" toml:"push-tags"`
+	// Allow running CI workflows manually with workflow_dispatch.
+	// default: false
+	WorkflowDispatch bool `json:"workflow-dispatch" yaml:"workflow-dispatch" toml:"workflow-dispatch"`
+	// Run CI workflows for merge queues with merge_group.
+	// default: false
+	MergeGroup bool `json:"merge-group" yaml:"merge-group" toml:"merge-group"`
+	// Cron schedule of the stale workflow.
+	// default: "0 0 * * *"
+	StaleCron string `json:"stale-cron" yaml:"stale-cron" toml:"stale-cron"`
+	// Cron schedule of the sage-ci-sync workflow.
+	// default: "0 0 1 * *"
+	SyncCron string `json:"sync-cron" yaml:"sync-cron" toml:"sync-cron"`
+	// Version of sage-ci which UpdateSageCi and the sage-ci-sync workflow update to, or a constraint
+	// such as "v0.4" or "~0.4.1" to stay on a minor line, see VersionConstraint.
+	// default: "latest"
+	SageCiVersion string `json:"sage-ci-version" yaml:"sage-ci-version" toml:"sage-ci-version"`
+
+	// Workflow policies
+
+	// Stale configures when the stale workflow marks and closes inactive issues and pull requests.
+	Stale StaleConfig `json:"stale" yaml:"stale" toml:"stale"`
+	// PRTitle configures the semantic pull request title check of the pr workflow.
+	PRTitle PRTitleConfig `json:"pr-title" yaml:"pr-title" toml:"pr-title"`
 
 	// Options
+
+	// Go versions in the CI test matrix.
 	// default: ["stable"]
-	GoVersions []string
-	// default: ["3.12"]
-	PythonVersions []string
+	GoVersions []string `json:"go-versions" yaml:"go-versions" toml:"go-versions"`
+	// Python versions in the CI test matrix.
+	// default: ["3.14"]
+	PythonVersions []string `json:"python-versions" yaml:"python-versions" toml:"python-versions"`
+	// Runner images in the CI test matrix.
 	// default: ["ubuntu-latest"]
-	OSVersions []string
+	OSVersions []string `json:"os-versions" yaml:"os-versions" toml:"os-versions"`
+	// GoTestOptions configures GoTest and GoBench per Go module.
+	// Key: Module path, or "*" for all modules. A module key takes precedence over "*".
+	// E.g. map[string]config.GoTestOptions{"*": {Count: 3}, "tools": {NoRace: true}}
+	GoTestOptions map[string]GoTestOptions `json:"go-test-options" yaml:"go-test-options" toml:"go-test-options"`
+	// Directory holding benchmark baselines, relative to the repository root.
+	// default: ".sage/bench"
+	GoBenchBaselineDir string `json:"go-bench-baseline-dir" yaml:"go-bench-baseline-dir" toml:"go-bench-baseline-dir"`
+	// PythonOptions configures Python targets per Python module.
+	// Key: Module path, or "*" for all modules. A module key takes precedence over "*".
+	// E.g. map[string]config.PythonOptions{"*": {Typechecker: config.TypecheckerTy}}
+	PythonOptions map[string]PythonOptions `json:"python-options" yaml:"python-options" toml:"python-options"`
+	// Go main packages to build into GoDistDir, relative to the repository root.
+	// Without binaries, GoBuild only compiles all packages and RunParallel doesn't run it.
+	// E.g. []string{"cmd/mytool"}
+	GoBinaries []string `json:"go-binaries" yaml:"go-binaries" toml:"go-binaries"`
+	// default: "dist"
+	GoDistDir string `json:"go-dist-dir" yaml:"go-dist-dir" toml:"go-dist-dir"`
+	// GOOS/GOARCH pairs built by GoCrossBuild.
+	// default: ["linux/amd64", "linux/arm64", "darwin/amd64", "darwin/arm64", "windows/amd64"]
+	GoPlatforms []string `json:"go-platforms" yaml:"go-platforms" toml:"go-platforms"`
+	// Package variable stamped with the git version via -ldflags -X.
+	// default: "main.version"
+	GoVersionVar string `json:"go-version-var" yaml:"go-version-var" toml:"go-version-var"`
+	// ModuleOptions overrides repo-wide settings for individual modules of any ecosystem.
+	// Key: Module path, as listed in GoModules, PythonModules, LuaModules or TerraformModules.
+	// In sage-ci.yaml and sage-ci.toml, module lists also accept entries with a path and
+	// these options, e.g. {path: "tools", os-versions: ["windows-latest"]}.
+	// E.g. map[string]config.ModuleOptions{"py": {PythonVersions: []string{"3.11"}}}
+	ModuleOptions map[string]ModuleOptions `json:"module-options" yaml:"module-options" toml:"module-options"`
+	// Use OpenTofu (tofu) instead of Terraform for Terraform targets.
+	// default: false
+	UseOpenTofu bool `json:"use-open-tofu" yaml:"use-open-tofu" toml:"use-open-tofu"`
 }
 
 // WithDefaults returns a copy of the config with default values applied.
@@ -61,9 +185,117 @@ func (c Config) WithDefaults() Config {
 	if len(c.Platforms) == 0 {
 		c.Platforms = []Platform{PlatformGitHub}
 	}
+	if c.Runner == "" {
+		c.Runner = RunnerMake
+	}
+	if c.GoBenchBaselineDir == "" {
+		c.GoBenchBaselineDir = ".sage/bench"
+	}
+	if c.GoDistDir == "" {
+		c.GoDistDir = "dist"
+	}
+	if len(c.GoPlatforms) == 0 {
+		c.GoPlatforms = []string{"linux/amd64", "linux/arm64", "darwin/amd64", "darwin/arm64", "windows/amd64"}
+	}
+	if c.GoVersionVar == "" {
+		c.GoVersionVar = "main.version"
+	}
+	if len(c.DocsExclude) == 0 {
+		c.DocsExclude = []string{".github/workflows/sage-ci-*.yml"}
+	}
+	if c.StaleCron == "" {
+		c.StaleCron = "0 0 * * *"
+	}
+	if c.SyncCron == "" {
+		c.SyncCron = "0 0 1 * *"
+	}
+	if c.SageCiVersion == "" {
+		c.SageCiVersion = "latest"
+	}
+	c.Stale = c.Stale.withDefaults()
+	c.PRTitle = c.PRTitle.withDefaults()
 	return c
 }
 
+// GoTestOptionsFor returns the GoTestOptions for the given module.
+// The module's ModuleOptions.Tags are included in Tags.
+func (c Config) GoTestOptionsFor(module string) GoTestOptions {
+	opts, ok := c.GoTestOptions[module]
+	if !ok {
+		opts = c.GoTestOptions["*"]
+	}
+	if tags := c.ModuleOptions[module].Tags; len(tags) > 0 {
+		opts.Tags = slices.Concat(tags, opts.Tags)
+	}
+	return opts
+}
+
+// ModuleOptionsFor returns the ModuleOptions for the given module,
+// with unset versions falling back to the repo-wide versions.
+func (c Config) ModuleOptionsFor(module string) ModuleOptions {
+	opts := c.ModuleOptions[module]
+	if len(opts.GoVersions) == 0 {
+		opts.GoVersions = c.GoVersions
+	}
+	if len(opts.PythonVersions) == 0 {
+		opts.PythonVersions = c.PythonVersions
+	}
+	if len(opts.OSVersions) == 0 {
+		opts.OSVersions = c.OSVersions
+	}
+	return opts
+}
+
+// HasModuleMatrix returns true if any of the given modules overrides the CI test matrix.
+func (c Config) HasModuleMatrix(modules []string) bool {
+	for _, module := range modules {
+		opts := c.ModuleOptions[module]
+		if len(opts.GoVersions) > 0 || len(opts.PythonVersions) > 0 || len(opts.OSVersions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// PythonOptionsFor returns the PythonOptions for the given module, with defaults applied.
+func (c Config) PythonOptionsFor(module string) PythonOptions {
+	opts, ok := c.PythonOptions[module]
+	if !ok {
+		opts = c.PythonOptions["*"]
+	}
+	if opts.Typechecker == "" {
+		opts.Typechecker = TypecheckerMypy
+	}
+	if opts.DistDir == "" {
+		opts.DistDir = "dist"
+	}
+	return opts
+}
+
+// PythonTypecheckers returns the typecheckers used by the configured Python modules.
+func (c Config) PythonTypecheckers() []PythonTypechecker {
+	var checkers []PythonTypechecker
+	for _, checker := range []PythonTypechecker{TypecheckerMypy, TypecheckerPyright, TypecheckerTy} {
+		for _, module := range c.PythonModules {
+			if c.PythonOptionsFor(module).Typechecker == checker {
+				checkers = append(checkers, checker)
+				break
+			}
+		}
+	}
+	return checkers
+}
+
+// HasPythonPackages returns true if any Python module is a distributable package.
+func (c Config) HasPythonPackages() bool {
+	for _, module := range c.PythonModules {
+		if c.PythonOptionsFor(module).Package {
+			return true
+		}
+	}
+	return false
+}
+
 // HasGo returns true if Go modules are configured.
 func (c Config) HasGo() bool {
 	return len(c.GoModules) > 0
@@ -79,33 +311,222 @@ func (c Config) HasLua() bool {
 	return len(c.LuaModules) > 0
 }
 
+// HasTerraform returns true if Terraform modules are configured.
+func (c Config) HasTerraform() bool {
+	return len(c.TerraformModules) > 0
+}
+
+// HasDocs returns true if Markdown or YAML files are configured.
+func (c Config) HasDocs() bool {
+	return len(c.MarkdownFiles) > 0 || len(c.YAMLFiles) > 0
+}
+
+// GoTestOptions configures go test for a Go module.
+// The zero value runs tests with the race detector and in shuffled order.
+type GoTestOptions struct {
+	// NoRace disables the race detector.
+	NoRace bool `json:"no-race" yaml:"no-race" toml:"no-race"`
+	// NoShuffle disables randomized test and benchmark order.
+	NoShuffle bool `json:"no-shuffle" yaml:"no-shuffle" toml:"no-shuffle"`
+	// Count runs each test n times, e.g. to hunt for flaky tests.
+	// default: 1
+	Count int `json:"count" yaml:"count" toml:"count"`
+	// Tags lists build tags passed via -tags.
+	Tags []string `json:"tags" yaml:"tags" toml:"tags"`
+	// FuzzTime enables a fuzz smoke run of each Fuzz* function with the given budget.
+	// E.g. "10s"
+	FuzzTime string `json:"fuzz-time" yaml:"fuzz-time" toml:"fuzz-time"`
+	// BenchCount runs each benchmark n times for GoBench.
+	// default: 6
+	BenchCount int `json:"bench-count" yaml:"bench-count" toml:"bench-count"`
+}
+
+// PythonOptions configures Python targets for a Python module.
+type PythonOptions struct {
+	// Typechecker selects the type checker run by PythonMypy, PythonPyright or PythonTy.
+	// default: "mypy"
+	Typechecker PythonTypechecker `json:"typechecker" yaml:"typechecker" toml:"typechecker"`
+	// Package marks the module as a distributable package,
+	// enabling PythonBuild and PythonSmoke.
+	Package bool `json:"package" yaml:"package" toml:"package"`
+	// DistDir is where PythonBuild writes sdists and wheels, relative to the module.
+	// default: "dist"
+	DistDir string `json:"dist-dir" yaml:"dist-dir" toml:"dist-dir"`
+	// ImportName is the top-level package imported by PythonSmoke.
+	// default: the wheel's distribution name
+	ImportName string `json:"import-name" yaml:"import-name" toml:"import-name"`
+}
+
+// ModuleOptions overrides repo-wide settings for a module.
+// Unset fields fall back to the repo-wide settings.
+type ModuleOptions struct {
+	// GoVersions replaces GoVersions in the module's CI test matrix.
+	GoVersions []string `json:"go-versions" yaml:"go-versions" toml:"go-versions"`
+	// PythonVersions replaces PythonVersions for PythonTestMatrix and the module's CI test matrix.
+	PythonVersions []string `json:"python-versions" yaml:"python-versions" toml:"python-versions"`
+	// OSVersions replaces OSVersions in the module's CI test matrix.
+	OSVersions []string `json:"os-versions" yaml:"os-versions" toml:"os-versions"`
+	// Tags lists Go build tags passed to all Go targets, in addition to GoTestOptions.Tags.
+	Tags []string `json:"tags" yaml:"tags" toml:"tags"`
+	// Args maps target names to extra arguments for the target's command.
+	// The arguments are inserted before the paths the command operates on.
+	// E.g. map[string][]string{"PythonTest": {"-x"}, "GoLint": {"--timeout", "10m"}}
+	Args map[string][]string `json:"args" yaml:"args" toml:"args"`
+	// Env sets environment variables for all targets run in the module.
+	Env map[string]string `json:"env" yaml:"env" toml:"env"`
+	// GoLintConfig is the golangci-lint configuration file, relative to the repository root.
+	// default: discovered by golangci-lint from the module directory
+	GoLintConfig string `json:"go-lint-config" yaml:"go-lint-config" toml:"go-lint-config"`
+}
+
+// StaleConfig configures the stale workflow, see https://github.com/actions/stale.
+type StaleConfig struct {
+	// Days of inactivity before an issue or pull request is marked stale, or -1 to never mark them stale.
+	// Set it with Days in Go.
+	// default: 30
+	DaysBeforeStale *int `json:"days-before-stale" yaml:"days-before-stale" toml:"days-before-stale"`
+	// Days of inactivity before a stale issue or pull request is closed, or -1 to never close them.
+	// Set it with Days in Go.
+	// default: 30
+	DaysBeforeClose *int `json:"days-before-close" yaml:"days-before-close" toml:"days-before-close"`
+	// Comment posted when an issue is marked stale.
+	// default: a message stating DaysBeforeStale and DaysBeforeClose
+	StaleIssueMessage string `json:"stale-issue-message" yaml:"stale-issue-message" toml:"stale-issue-message"`
+	// Comment posted when a pull request is marked stale.
+	// default: a message stating DaysBeforeStale and DaysBeforeClose
+	StalePRMessage string `json:"stale-pr-message" yaml:"stale-pr-message" toml:"stale-pr-message"`
+	// Comment posted when a stale issue is closed.
+	// default: "This issue was closed because it has been stalled for a long time with no activity."
+	CloseIssueMessage string `json:"close-issue-message" yaml:"close-issue-message" toml:"close-issue-message"`
+	// Comment posted when a stale pull request is closed.
+	// default: "This PR was closed because it has been stalled for a long time with no activity."
+	ClosePRMessage string `json:"close-pr-message" yaml:"close-pr-message" toml:"close-pr-message"`
+	// Labels exempting issues and pull requests from being marked stale.
+	// default: ["dependencies", "pinned", "bug"]
+	ExemptLabels []string `json:"exempt-labels" yaml:"exempt-labels" toml:"exempt-labels"`
+}
+
+// Days returns a pointer to days, for setting StaleConfig.DaysBeforeStale and DaysBeforeClose,
+// e.g. config.StaleConfig{DaysBeforeClose: config.Days(-1)}.
+func Days(days int) *int {
+	return &days
+}
+
+// withDefaults returns a copy of the stale config with default values applied.
+func (s StaleConfig) withDefaults() StaleConfig {
+	if s.DaysBeforeStale == nil {
+		s.DaysBeforeStale = Days(30)
+	}
+	if s.DaysBeforeClose == nil {
+		s.DaysBeforeClose = Days(30)
+	}
+	var closing string
+	if *s.DaysBeforeClose >= 0 {
+		closing = fmt.Sprintf(" or it will be closed in another **%d days**", *s.DaysBeforeClose)
+	}
+	if s.StaleIssueMessage == "" {
+		s.StaleIssueMessage = fmt.Sprintf("This issue has been open for **%d days** with no activity. "+
+			"Remove the stale label or add a comment%s.", *s.DaysBeforeStale, closing)
+	}
+	if s.StalePRMessage == "" {
+		s.StalePRMessage = fmt.Sprintf("This PR has been open for **%d days** with no activity. "+
+			"Remove the stale label or add a comment%s.", *s.DaysBeforeStale, closing)
+	}
+	if s.CloseIssueMessage == "" {
+		s.CloseIssueMessage = "This issue was closed because it has been stalled for a long time with no activity."
+	}
+	if s.ClosePRMessage == "" {
+		s.ClosePRMessage = "This PR was closed because it has been stalled for a long time with no activity."
+	}
+	if len(s.ExemptLabels) == 0 {
+		s.ExemptLabels = []string{"dependencies", "pinned", "bug"}
+	}
+	return s
+}
+
+// PRTitleConfig configures the semantic pull request title check,
+// see https://github.com/amannn/action-semantic-pull-request.
+type PRTitleConfig struct {
+	// Conventional commit types allowed in pull request titles.
+	// default: ["build", "chore", "ci", "docs", "feat", "fix", "merge", "perf", "refactor", "revert", "style",
+	// "test", "wip"]
+	Types []string `json:"types" yaml:"types" toml:"types"`
+	// Regular expressions of allowed scopes.
+	// default: [".+"]
+	Scopes []string `json:"scopes" yaml:"scopes" toml:"scopes"`
+	// Require a scope, e.g. "feat(api): ..." instead of "feat: ...".
+	// default: false
+	RequireScope bool `json:"require-scope" yaml:"require-scope" toml:"require-scope"`
+	// Regular expression the subject after the type and scope must match.
+	// default: "^(?![A-Z]).+$" (the subject doesn't start with an uppercase letter)
+	SubjectPattern string `json:"subject-pattern" yaml:"subject-pattern" toml:"subject-pattern"`
+	// Labels skipping the check, e.g. for release pull requests.
+	// default: ["autorelease: pending"]
+	IgnoreLabels []string `json:"ignore-labels" yaml:"ignore-labels" toml:"ignore-labels"`
+}
+
+// withDefaults returns a copy of the pull request title config with default values applied.
+func (p PRTitleConfig) withDefaults() PRTitleConfig {
+	if len(p.Types) == 0 {
+		p.Types = []string{
+			"build", "chore", "ci", "docs", "feat", "fix", "merge",
+			"perf", "refactor", "revert", "style", "test", "wip",
+		}
+	}
+	if len(p.Scopes) == 0 {
+		p.Scopes = []string{".+"}
+	}
+	if p.SubjectPattern == "" {
+		p.SubjectPattern = "^(?![A-Z]).+$"
+	}
+	if len(p.IgnoreLabels) == 0 {
+		p.IgnoreLabels = []string{"autorelease: pending"}
+	}
+	return p
+}
+
 // SkipTargets maps target names to modules that should be skipped.
-// Key: Target name (e.g. "GoTest").
-// Value: List of modules to skip. Use "*" to skip all modules.
+// Key: Target name or pattern (e.g. "GoTest", "Python*" or "re:^Go(Lint|Format)$").
+// Value: List of modules or module patterns to skip (e.g. "tools", "examples/**").
+// Use "*" to skip all modules.
+// See MatchPattern for the pattern syntax.
 type SkipTargets map[string][]string
 
 // ShouldSkip returns true if the target should be skipped for the given module.
 func (s SkipTargets) ShouldSkip(target, module string) bool {
-	skippedModules, ok := s[target]
-	if !ok {
-		return false
+	_, ok := s.Rule(target, module)
+	return ok
+}
+
+// Rule returns the rule skipping the target for the given module, formatted as "key: module",
+// e.g. "Python*: examples/**". It returns false if the target is not skipped for the module.
+func (s SkipTargets) Rule(target, module string) (string, bool) {
+	for _, key := range s.keysFor(target) {
+		for _, m := range s[key] {
+			if m == "*" || MatchPattern(m, module) {
+				return key + ": " + m, true
+			}
+		}
 	}
-	for _, m := range skippedModules {
-		if m == "*" || m == module {
-			return true
+	return "", false
+}
+
+// Rules returns the rules skipping the target for some module, formatted like Rule.
+func (s SkipTargets) Rules(target string) []string {
+	var rules []string
+	for _, key := range s.keysFor(target) {
+		for _, m := range s[key] {
+			rules = append(rules, key+": "+m)
 		}
 	}
-	return false
+	return rules
 }
 
 // IsFullySkipped returns true if the target is skipped for all given modules.
+// Without modules, the target is only fully skipped if it is skipped for "*".
 func (s SkipTargets) IsFullySkipped(target string, modules []string) bool {
-	skippedModules, ok := s[target]
-	if !ok {
-		return false
-	}
-	for _, m := range skippedModules {
-		if m == "*" {
+	for _, key := range s.keysFor(target) {
+		if slices.Contains(s[key], "*") {
 			return true
 		}
 	}
@@ -119,3 +540,21 @@ func (s SkipTargets) IsFullySkipped(target string, modules []string) bool {
 	}
 	return true
 }
+
+// keysFor returns the keys matching target, in sorted order.
+func (s SkipTargets) keysFor(target string) []string {
+	var keys []string
+	for key := range s {
+		if key == target || MatchPattern(key, target) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// ShouldSkipWorkflow returns true if the workflow name, without the .yml extension,
+// matches any of SkipWorkflows. See MatchPattern for the pattern syntax.
+func (c Config) ShouldSkipWorkflow(name string) bool {
+	return matchesAny(c.SkipWorkflows, name)
+}
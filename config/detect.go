@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Detect returns a Config with the Go, Python and Lua modules found below root:
+//   - Go modules are directories with a go.mod.
+//   - Python modules are directories with a pyproject.toml.
+//   - Lua sources make the repository root a Lua module.
+//
+// Hidden directories and vendor, node_modules and testdata directories are not searched.
+func Detect(root string) (Config, error) {
+	var cfg Config
+	var hasLua bool
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || slices.Contains(skipDetectDirs, name)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		module := filepath.ToSlash(rel)
+		switch name := d.Name(); {
+		case name == "go.mod":
+			cfg.GoModules = append(cfg.GoModules, module)
+		case name == "pyproject.toml":
+			cfg.PythonModules = append(cfg.PythonModules, module)
+		case strings.HasSuffix(name, ".lua"):
+			hasLua = true
+		}
+		return nil
+	})
+	if err != nil {
+		return Config{}, fmt.Errorf("detect modules in %s: %w", root, err)
+	}
+	if hasLua {
+		cfg.LuaModules = []string{"."}
+	}
+	return cfg, nil
+}
+
+// skipDetectDirs lists directories Detect does not search, besides hidden directories.
+var skipDetectDirs = []string{"vendor", "node_modules", "testdata"}
+
+// PlatformForRemote returns the platform hosting the git remote URL,
+// e.g. "git@github.com:owner/repo.git" or "https://codeberg.org/owner/repo".
+// Self-hosted GitLab instances are recognized by a host name containing "gitlab".
+func PlatformForRemote(url string) (Platform, bool) {
+	host := url
+	if _, rest, ok := strings.Cut(host, "://"); ok {
+		host = rest
+	}
+	if _, rest, ok := strings.Cut(host, "@"); ok {
+		host = rest
+	}
+	host, _, _ = strings.Cut(host, "/")
+	host, _, _ = strings.Cut(host, ":")
+	host = strings.ToLower(host)
+	switch {
+	case host == "github.com" || strings.HasSuffix(host, ".github.com"):
+		return PlatformGitHub, true
+	case host == "codeberg.org":
+		return PlatformCodeberg, true
+	case strings.Contains(host, "gitlab"):
+		return PlatformGitLab, true
+	}
+	return "", false
+}
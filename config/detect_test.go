@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{
+		"go.mod",
+		"tools/go.mod",
+		"tools/testdata/go.mod",
+		"py/pyproject.toml",
+		"py/.venv/lib/pyproject.toml",
+		"node_modules/pkg/pyproject.toml",
+		"lua/plugin/init.lua",
+		".sage/go.mod",
+	} {
+		p := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := Detect(root)
+	if err != nil {
+		t.Fatalf("Detect() failed: %v", err)
+	}
+	if want := []string{".", "tools"}; !slices.Equal(cfg.GoModules, want) {
+		t.Errorf("GoModules = %v, want %v", cfg.GoModules, want)
+	}
+	if want := []string{"py"}; !slices.Equal(cfg.PythonModules, want) {
+		t.Errorf("PythonModules = %v, want %v", cfg.PythonModules, want)
+	}
+	if want := []string{"."}; !slices.Equal(cfg.LuaModules, want) {
+		t.Errorf("LuaModules = %v, want %v", cfg.LuaModules, want)
+	}
+}
+
+func TestPlatformForRemote(t *testing.T) {
+	for _, tt := range []struct {
+		url  string
+		want Platform
+		ok   bool
+	}{
+		{url: "git@github.com:owner/repo.git", want: PlatformGitHub, ok: true},
+		{url: "https://github.com/owner/repo", want: PlatformGitHub, ok: true},
+		{url: "ssh://git@codeberg.org/owner/repo.git", want: PlatformCodeberg, ok: true},
+		{url: "https://gitlab.example.com:8443/group/repo.git", want: PlatformGitLab, ok: true},
+		{url: "git@gitlab.com:group/repo.git", want: PlatformGitLab, ok: true},
+		{url: "https://git.example.com/repo.git"},
+	} {
+		got, ok := PlatformForRemote(tt.url)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("PlatformForRemote(%q) = %q, %v, want %q, %v", tt.url, got, ok, tt.want, tt.ok)
+		}
+	}
+}
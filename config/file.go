@@ -0,0 +1,310 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames lists the declarative configuration file names, in lookup order.
+// The files are looked up in the .sage directory.
+var FileNames = []string{"sage-ci.yaml", "sage-ci.yml", "sage-ci.toml"}
+
+// Load reads the declarative configuration file in dir, if any, and merges it into base.
+//
+// Precedence rules:
+//   - Values set in the file take precedence over values set in Go.
+//   - Fields not set in the file keep their Go values. Empty lists and tables, such as the
+//     `go-modules: []` placeholders of the configuration templates, count as not set.
+//   - Tables (Stale, PRTitle) are merged per field, so setting one of their keys keeps the others.
+//   - Maps (SkipTargets, GoTestOptions, PythonOptions, ModuleOptions) are merged per key,
+//     with file entries replacing Go entries for the same key.
+//   - A boolean can only be enabled from the file, not disabled.
+//   - Defaults (see WithDefaults) apply after merging.
+//
+// Without a configuration file, base is returned unchanged.
+func Load(dir string, base Config) (Config, error) {
+	path, ok := FindFile(dir)
+	if !ok {
+		return base, nil
+	}
+	file, err := LoadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return base.Merge(file), nil
+}
+
+// MustLoad is like Load but panics on error.
+// It is intended for initializing the cfg variable in .sage/sagefile.go.
+func MustLoad(dir string, base Config) Config {
+	cfg, err := Load(dir, base)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+// FindFile returns the path of the first declarative configuration file found in dir.
+func FindFile(dir string) (string, bool) {
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// moduleListKeys are the file keys of the module lists, which accept structured entries.
+var moduleListKeys = []string{"go-modules", "python-modules", "lua-modules", "terraform-modules"}
+
+// LoadFile reads a YAML or TOML configuration file, based on its extension.
+// Unknown keys are reported as errors.
+func LoadFile(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read config file: %w", err)
+	}
+	ext := filepath.Ext(path)
+	content, err = expandModuleEntries(ext, content)
+	if err != nil {
+		return Config{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	var cfg Config
+	switch ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, fmt.Errorf("parse %s: %w", path, err)
+		}
+	case ".toml":
+		metadata, err := toml.Decode(string(content), &cfg)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse %s: %w", path, err)
+		}
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, 0, len(undecoded))
+			for _, key := range undecoded {
+				keys = append(keys, key.String())
+			}
+			return Config{}, fmt.Errorf("parse %s: unknown keys: %s", path, strings.Join(keys, ", "))
+		}
+	default:
+		return Config{}, fmt.Errorf("unsupported config file extension: %s", ext)
+	}
+	return cfg, nil
+}
+
+// expandModuleEntries rewrites structured module list entries, such as
+// {path: "tools", os-versions: ["windows-latest"]}, into a plain path and a module-options entry.
+// Content without structured entries is returned unchanged.
+func expandModuleEntries(ext string, content []byte) ([]byte, error) {
+	var raw map[string]any
+	switch ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if _, err := toml.Decode(string(content), &raw); err != nil {
+			return nil, err
+		}
+	default:
+		return content, nil
+	}
+	var expanded bool
+	for _, key := range moduleListKeys {
+		entries, _ := raw[key].([]any)
+		for i, entry := range entries {
+			options, ok := entry.(map[string]any)
+			if !ok {
+				continue
+			}
+			path, ok := options["path"].(string)
+			if !ok || path == "" {
+				return nil, fmt.Errorf("%s[%d]: module entries need a path", key, i)
+			}
+			delete(options, "path")
+			moduleOptions, _ := raw["module-options"].(map[string]any)
+			if moduleOptions == nil {
+				moduleOptions = map[string]any{}
+				raw["module-options"] = moduleOptions
+			}
+			if _, ok := moduleOptions[path]; ok {
+				return nil, fmt.Errorf("%s[%d]: module-options for %q are already set", key, i, path)
+			}
+			moduleOptions[path] = options
+			entries[i] = path
+			expanded = true
+		}
+	}
+	if !expanded {
+		return content, nil
+	}
+	if ext == ".toml" {
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+			return nil, fmt.Errorf("encode expanded config: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+	expandedContent, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("encode expanded config: %w", err)
+	}
+	return expandedContent, nil
+}
+
+// Merge returns a copy of c with the values set in other applied on top, see Load for the precedence rules.
+func (c Config) Merge(other Config) Config {
+	mergeFields(reflect.ValueOf(&c).Elem(), reflect.ValueOf(other))
+	return c
+}
+
+// mergeFields sets the fields of the struct dst which are set in src. Struct fields, also behind pointers,
+// are merged per field, and maps per key.
+func mergeFields(dst, src reflect.Value) {
+	for i := range dst.NumField() {
+		s, d := src.Field(i), dst.Field(i)
+		if s.IsZero() || (s.Kind() == reflect.Slice || s.Kind() == reflect.Map) && s.Len() == 0 {
+			continue
+		}
+		switch {
+		case s.Kind() == reflect.Struct:
+			mergeFields(d, s)
+		case s.Kind() == reflect.Pointer && s.Elem().Kind() == reflect.Struct && !d.IsNil():
+			// Merge into a copy, so that the struct d points at is left unchanged.
+			merged := reflect.New(d.Elem().Type())
+			merged.Elem().Set(d.Elem())
+			mergeFields(merged.Elem(), s.Elem())
+			d.Set(merged)
+		case s.Kind() == reflect.Map && !d.IsNil():
+			combined := reflect.MakeMapWithSize(d.Type(), d.Len()+s.Len())
+			for _, m := range []reflect.Value{d, s} {
+				iter := m.MapRange()
+				for iter.Next() {
+					combined.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+			d.Set(combined)
+		default:
+			d.Set(s)
+		}
+	}
+}
+
+// SetFileValue sets the top-level key of the YAML or TOML configuration file at path to value,
+// a string, bool or []string, adding the key if it isn't set. Comments and other keys are kept.
+func SetFileValue(path, key string, value any) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	var separator string
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		separator = ": "
+	case ".toml":
+		separator = " = "
+	default:
+		return fmt.Errorf("unsupported config file extension: %s", ext)
+	}
+	// JSON strings, booleans and arrays of strings are valid in both YAML and TOML.
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", key, err)
+	}
+	if list, ok := value.([]string); ok {
+		// Match the list style of the configuration templates.
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			b, _ := json.Marshal(item)
+			items = append(items, string(b))
+		}
+		encoded = []byte("[" + strings.Join(items, ", ") + "]")
+	}
+	content = setFileLine(content, key+separator+string(encoded), key, separator == " = ")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("write config file: %w", err)
+	}
+	return nil
+}
+
+// setFileLine replaces the top-level key in content with line, or adds line at the end of the top-level keys:
+// at the end of the file, or before the first table if isTOML. A value continued on indented lines, such as a
+// YAML block sequence, is replaced as a whole.
+func setFileLine(content []byte, line, key string, isTOML bool) []byte {
+	assign := ":"
+	if isTOML {
+		assign = "="
+	}
+	lines := strings.Split(string(content), "\n")
+	insertAt := len(lines)
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		if isTOML && strings.HasPrefix(strings.TrimSpace(l), "[") {
+			insertAt = i
+			break
+		}
+		name, _, ok := strings.Cut(l, assign)
+		if !ok || strings.Trim(strings.TrimSpace(name), `"'`) != key || strings.TrimLeft(name, " \t") != name {
+			continue
+		}
+		end := i + 1
+		// A multi-line array, whose closing bracket may be at the start of a line.
+		for depth := bracketDepth(l[len(name)+1:]); end < len(lines) && depth > 0; end++ {
+			depth += bracketDepth(lines[end])
+		}
+		for end < len(lines) && (strings.HasPrefix(lines[end], " ") || strings.HasPrefix(lines[end], "\t") ||
+			(!isTOML && strings.HasPrefix(lines[end], "- "))) {
+			end++
+		}
+		return []byte(strings.Join(slices.Replace(lines, i, end, line), "\n"))
+	}
+	// Keep the blank lines before the first table, or the final newline.
+	for insertAt > 0 && strings.TrimSpace(lines[insertAt-1]) == "" {
+		insertAt--
+	}
+	return []byte(strings.Join(slices.Insert(lines, insertAt, line), "\n"))
+}
+
+// bracketDepth returns the number of brackets opened minus the number closed on line,
+// ignoring brackets in quoted strings and comments.
+func bracketDepth(line string) int {
+	var depth int
+	var quote rune
+	var escaped bool
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == '\\' && quote == '"' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return depth
+		case r == '[' || r == '{':
+			depth++
+		case r == ']' || r == '}':
+			depth--
+		}
+	}
+	return depth
+}
@@ -0,0 +1,330 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	base := Config{
+		GoModules:     []string{"."},
+		PythonModules: []string{"py"},
+		SkipTargets:   SkipTargets{"GoLint": {"tools"}, "GoTest": {"*"}},
+	}
+	want := Config{
+		GoModules:      []string{".", "tools"},
+		PythonModules:  []string{"py"},
+		GoVersions:     []string{"1.24", "stable"},
+		PythonVersions: []string{"3.12"},
+		SkipTargets:    SkipTargets{"GoLint": {"tools"}, "GoTest": {"."}, "PythonMypy": {"py"}},
+		PythonOptions:  map[string]PythonOptions{"py": {Typechecker: TypecheckerTy}},
+		UseOpenTofu:    true,
+	}
+
+	for _, tt := range []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "sage-ci.yaml",
+			content: `go-modules: [".", "tools"]
+go-versions: ["1.24", "stable"]
+python-versions: ["3.12"]
+skip-targets:
+  GoTest: ["."]
+  PythonMypy: ["py"]
+python-options:
+  py:
+    typechecker: ty
+use-open-tofu: true
+`,
+		},
+		{
+			name: "sage-ci.toml",
+			content: `go-modules = [".", "tools"]
+go-versions = ["1.24", "stable"]
+python-versions = ["3.12"]
+use-open-tofu = true
+
+[skip-targets]
+GoTest = ["."]
+PythonMypy = ["py"]
+
+[python-options.py]
+typechecker = "ty"
+`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.name), []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Load(dir, base)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoad_noFile(t *testing.T) {
+	base := Config{GoModules: []string{"."}}
+	got, err := Load(t.TempDir(), base)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, base) {
+		t.Errorf("Load() = %+v, want %+v", got, base)
+	}
+}
+
+func TestLoadFile_unknownKey(t *testing.T) {
+	for name, content := range map[string]string{
+		"sage-ci.yaml": "go-module: [\".\"]\n",
+		"sage-ci.toml": "go-module = [\".\"]\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadFile(path)
+			if err == nil || !strings.Contains(err.Error(), "go-module") {
+				t.Errorf("LoadFile() error = %v, want unknown key error mentioning go-module", err)
+			}
+		})
+	}
+}
+
+func TestLoadFile_moduleEntries(t *testing.T) {
+	want := Config{
+		GoModules:     []string{".", "tools"},
+		PythonModules: []string{"py"},
+		ModuleOptions: map[string]ModuleOptions{
+			"tools": {OSVersions: []string{"ubuntu-latest", "windows-latest"}, Tags: []string{"integration"}},
+			"py":    {PythonVersions: []string{"3.11"}, Env: map[string]string{"FOO": "bar"}},
+		},
+	}
+	for name, content := range map[string]string{
+		"sage-ci.yaml": `go-modules:
+  - "."
+  - path: tools
+    os-versions: [ubuntu-latest, windows-latest]
+    tags: [integration]
+python-modules:
+  - path: py
+    python-versions: ["3.11"]
+    env:
+      FOO: bar
+`,
+		"sage-ci.toml": `go-modules = [
+  ".",
+  { path = "tools", os-versions = ["ubuntu-latest", "windows-latest"], tags = ["integration"] },
+]
+python-modules = [{ path = "py", python-versions = ["3.11"], env = { FOO = "bar" } }]
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadFile(path)
+			if err != nil {
+				t.Fatalf("LoadFile failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("LoadFile() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_moduleEntryUnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sage-ci.yaml")
+	content := "go-modules:\n  - path: tools\n    os-version: [windows-latest]\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "os-version") {
+		t.Errorf("LoadFile() error = %v, want unknown key error mentioning os-version", err)
+	}
+}
+
+func TestSetFileValue(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name: "sage-ci.yaml",
+			content: `# Project config.
+go-modules: ["."]
+skip-workflows:
+  - sage-ci-stale
+
+lua-modules: []
+`,
+			want: `# Project config.
+go-modules: ["."]
+skip-workflows: ["sage-ci-stale", "sage-ci-go-ci"]
+
+lua-modules: []
+skip-targets-file: true
+`,
+		},
+		{
+			name: "sage-ci.toml",
+			content: `# Project config.
+go-modules = ["."]
+skip-workflows = ["sage-ci-stale"]
+
+[skip-targets]
+GoTest = ["."]
+`,
+			want: `# Project config.
+go-modules = ["."]
+skip-workflows = ["sage-ci-stale", "sage-ci-go-ci"]
+skip-targets-file = true
+
+[skip-targets]
+GoTest = ["."]
+`,
+		},
+		{
+			name: "multi-line.toml",
+			content: `skip-workflows = [
+  "sage-ci-stale", # "]"
+]
+go-modules = ["."]
+`,
+			want: `skip-workflows = ["sage-ci-stale", "sage-ci-go-ci"]
+go-modules = ["."]
+skip-targets-file = true
+`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.name)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := SetFileValue(path, "skip-workflows", []string{"sage-ci-stale", "sage-ci-go-ci"}); err != nil {
+				t.Fatalf("SetFileValue() failed: %v", err)
+			}
+			if err := SetFileValue(path, "skip-targets-file", true); err != nil {
+				t.Fatalf("SetFileValue() failed: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("SetFileValue() wrote\n%s\nwant\n%s", got, tt.want)
+			}
+			if _, err := LoadFile(path); err != nil {
+				t.Errorf("LoadFile() after SetFileValue() failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad_emptyListsKeepGoValues(t *testing.T) {
+	base := Config{
+		GoModules:     []string{"."},
+		SkipWorkflows: []string{"stale"},
+		SkipTargets:   SkipTargets{"GoLint": {"tools"}},
+	}
+	for name, content := range map[string]string{
+		"sage-ci.yaml": "go-modules: []\nskip-workflows: []\nskip-targets: {}\n",
+		"sage-ci.toml": "go-modules = []\nskip-workflows = []\n\n[skip-targets]\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Load(dir, base)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, base) {
+				t.Errorf("Load() = %+v, want %+v", got, base)
+			}
+		})
+	}
+}
+
+func TestLoad_staleDays(t *testing.T) {
+	for name, content := range map[string]string{
+		"sage-ci.yaml": "stale:\n  days-before-stale: 0\n  days-before-close: -1\n",
+		"sage-ci.toml": "[stale]\ndays-before-stale = 0\ndays-before-close = -1\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Load(dir, Config{})
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			// 0 and -1 are kept rather than defaulted to 30.
+			stale := got.WithDefaults().Stale
+			if *stale.DaysBeforeStale != 0 || *stale.DaysBeforeClose != -1 {
+				t.Errorf("Stale days = %d, %d, want 0, -1", *stale.DaysBeforeStale, *stale.DaysBeforeClose)
+			}
+			if err := got.Validate(dir, nil, nil); err != nil {
+				t.Errorf("Validate failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad_partialTable(t *testing.T) {
+	base := Config{
+		Stale: StaleConfig{
+			DaysBeforeStale: Days(60),
+			DaysBeforeClose: Days(14),
+			ExemptLabels:    []string{"pinned"},
+		},
+		PRTitle: PRTitleConfig{Types: []string{"feat", "fix"}},
+	}
+	for name, content := range map[string]string{
+		"sage-ci.yaml": "stale:\n  days-before-close: 7\npr-title:\n  require-scope: true\n",
+		"sage-ci.toml": "[stale]\ndays-before-close = 7\n\n[pr-title]\nrequire-scope = true\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Load(dir, base)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			want := Config{
+				Stale: StaleConfig{
+					DaysBeforeStale: Days(60),
+					DaysBeforeClose: Days(7),
+					ExemptLabels:    []string{"pinned"},
+				},
+				PRTitle: PRTitleConfig{Types: []string{"feat", "fix"}, RequireScope: true},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load() = %+v, want %+v", got, want)
+			}
+			if *base.Stale.DaysBeforeClose != 14 {
+				t.Errorf("Load() changed the base DaysBeforeClose to %d", *base.Stale.DaysBeforeClose)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// regexpPrefix marks a pattern as a regular expression instead of a glob.
+const regexpPrefix = "re:"
+
+// MatchPattern reports whether name matches pattern.
+//
+// Patterns are matched as follows:
+//   - A pattern starting with "re:" is a regular expression which must match all of name,
+//     e.g. "re:^Python(Mypy|Ty)$" or "re:examples/.*".
+//   - Any other pattern is a doublestar glob, e.g. "Python*", "examples/**" or "sage-ci-*-ci".
+//     A glob without wildcards only matches name exactly.
+//
+// Invalid patterns match nothing, see ValidatePattern.
+func MatchPattern(pattern, name string) bool {
+	if expr, ok := strings.CutPrefix(pattern, regexpPrefix); ok {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		return err == nil && re.MatchString(name)
+	}
+	ok, err := doublestar.Match(pattern, name)
+	return err == nil && ok
+}
+
+// ValidatePattern returns an error if pattern is not a valid glob or regular expression.
+func ValidatePattern(pattern string) error {
+	if expr, ok := strings.CutPrefix(pattern, regexpPrefix); ok {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+		}
+		return nil
+	}
+	if !doublestar.ValidatePattern(pattern) {
+		return fmt.Errorf("invalid glob pattern %q", pattern)
+	}
+	return nil
+}
+
+// isPattern reports whether pattern is a regular expression or a glob with wildcards.
+func isPattern(pattern string) bool {
+	return strings.HasPrefix(pattern, regexpPrefix) || strings.ContainsAny(pattern, "*?[{")
+}
+
+// matchesAny reports whether name matches any of patterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if MatchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "GoTest", name: "GoTest", want: true},
+		{pattern: "GoTest", name: "GoTestMatrix", want: false},
+		{pattern: "Python*", name: "PythonMypy", want: true},
+		{pattern: "Python*", name: "GoTest", want: false},
+		{pattern: "sage-ci-*-ci", name: "sage-ci-go-ci", want: true},
+		{pattern: "sage-ci-*-ci", name: "sage-ci-release", want: false},
+		{pattern: "examples/**", name: "examples", want: true},
+		{pattern: "examples/**", name: "examples/a/b", want: true},
+		{pattern: "examples/*", name: "examples/a/b", want: false},
+		{pattern: "tools", name: "tools/sub", want: false},
+		{pattern: "re:^Go(Lint|Format)$", name: "GoLint", want: true},
+		{pattern: "re:Go(Lint|Format)", name: "GoLintExtra", want: false},
+		{pattern: "re:examples/.*", name: "examples/a/b", want: true},
+		{pattern: "re:(", name: "(", want: false},
+		{pattern: "[", name: "[", want: false},
+	} {
+		if got := MatchPattern(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchPattern(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSkipTargets(t *testing.T) {
+	skip := SkipTargets{
+		"GoLint":               {"tools"},
+		"Python*":              {"examples/**"},
+		"re:^Terraform(Lint)$": {"*"},
+		"GoTest":               {"re:internal/.*"},
+	}
+	for _, tt := range []struct {
+		target string
+		module string
+		want   bool
+	}{
+		{target: "GoLint", module: "tools", want: true},
+		{target: "GoLint", module: ".", want: false},
+		{target: "PythonTest", module: "examples", want: true},
+		{target: "PythonMypy", module: "examples/demo", want: true},
+		{target: "PythonTest", module: "src", want: false},
+		{target: "TerraformLint", module: "infra/prod", want: true},
+		{target: "TerraformFormat", module: "infra/prod", want: false},
+		{target: "GoTest", module: "internal/foo", want: true},
+		{target: "GoTest", module: "internal", want: false},
+		{target: "LuaFormat", module: ".", want: false},
+	} {
+		if got := skip.ShouldSkip(tt.target, tt.module); got != tt.want {
+			t.Errorf("ShouldSkip(%q, %q) = %v, want %v", tt.target, tt.module, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		target  string
+		modules []string
+		want    bool
+	}{
+		{target: "GoLint", modules: []string{"tools"}, want: true},
+		{target: "GoLint", modules: []string{".", "tools"}, want: false},
+		{target: "PythonTest", modules: []string{"examples/a", "examples/b"}, want: true},
+		{target: "PythonTest", modules: []string{"examples/a", "src"}, want: false},
+		{target: "PythonTest", modules: nil, want: false},
+		{target: "TerraformLint", modules: nil, want: true},
+		{target: "TerraformLint", modules: []string{"infra"}, want: true},
+		{target: "TerraformValidate", modules: []string{"infra"}, want: false},
+	} {
+		if got := skip.IsFullySkipped(tt.target, tt.modules); got != tt.want {
+			t.Errorf("IsFullySkipped(%q, %q) = %v, want %v", tt.target, tt.modules, got, tt.want)
+		}
+	}
+
+	if got, want := skip.Rules("PythonTest"), []string{"Python*: examples/**"}; !slices.Equal(got, want) {
+		t.Errorf("Rules(%q) = %q, want %q", "PythonTest", got, want)
+	}
+	if got := skip.Rules("LuaFormat"); len(got) != 0 {
+		t.Errorf("Rules(%q) = %q, want none", "LuaFormat", got)
+	}
+}
+
+func TestShouldSkipWorkflow(t *testing.T) {
+	cfg := Config{SkipWorkflows: []string{"sage-ci-stale", "sage-ci-*-ci"}}
+	for _, tt := range []struct {
+		name string
+		want bool
+	}{
+		{name: "sage-ci-stale", want: true},
+		{name: "sage-ci-go-ci", want: true},
+		{name: "sage-ci-python-ci", want: true},
+		{name: "sage-ci-release", want: false},
+		{name: "sage-ci-sta", want: false},
+	} {
+		if got := cfg.ShouldSkipWorkflow(tt.name); got != tt.want {
+			t.Errorf("ShouldSkipWorkflow(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
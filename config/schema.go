@@ -0,0 +1,206 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// SchemaURL is where the JSON Schema for the sage-ci configuration file is published.
+const SchemaURL = "https://raw.githubusercontent.com/fredrikaverpil/sage-ci/main/sage-ci.schema.json"
+
+// configSource is parsed for field doc comments, which become schema descriptions.
+//
+//go:embed config.go
+var configSource string
+
+// Schema returns a JSON Schema describing the sage-ci configuration file.
+// targetNames are the target names accepted as SkipTargets keys, besides patterns.
+func Schema(targetNames []string) ([]byte, error) {
+	docs, err := fieldDocs()
+	if err != nil {
+		return nil, err
+	}
+	g := schemaGenerator{
+		docs:        docs,
+		targetNames: targetNames,
+		defaults: map[reflect.Type]reflect.Value{
+			reflect.TypeFor[Config]():        reflect.ValueOf(Config{}.WithDefaults()),
+			reflect.TypeFor[PythonOptions](): reflect.ValueOf(Config{}.PythonOptionsFor("")),
+			reflect.TypeFor[StaleConfig]():   reflect.ValueOf(Config{}.WithDefaults().Stale),
+			reflect.TypeFor[PRTitleConfig](): reflect.ValueOf(Config{}.WithDefaults().PRTitle),
+		},
+		defs: map[string]any{},
+	}
+	schema := g.object(reflect.TypeFor[Config]())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaURL
+	schema["title"] = "sage-ci configuration"
+	schema["$defs"] = g.defs
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal schema: %w", err)
+	}
+	return append(b, '\n'), nil
+}
+
+type schemaGenerator struct {
+	docs        map[string]string
+	targetNames []string
+	defaults    map[reflect.Type]reflect.Value
+	defs        map[string]any
+}
+
+// object returns the schema for a struct type, with one property per tagged field.
+func (g schemaGenerator) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	defaults, hasDefaults := g.defaults[t]
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		property := g.value(field.Type)
+		if slices.Contains(moduleListKeys, name) {
+			property["items"] = map[string]any{
+				"anyOf": []any{property["items"], g.moduleEntry()},
+			}
+		}
+		if doc := g.docs[t.Name()+"."+field.Name]; doc != "" {
+			property["description"] = doc
+		}
+		if hasDefaults {
+			if v := defaults.Field(i); !v.IsZero() {
+				property["default"] = v.Interface()
+			}
+		}
+		properties[name] = property
+	}
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if doc := g.docs[t.Name()]; doc != "" {
+		schema["description"] = doc
+	}
+	return schema
+}
+
+// value returns the schema for a field type.
+func (g schemaGenerator) value(t reflect.Type) map[string]any {
+	switch t {
+	case reflect.TypeFor[Platform]():
+		return enum(PlatformGitHub, PlatformGitLab, PlatformCodeberg)
+	case reflect.TypeFor[PythonTypechecker]():
+		return enum(TypecheckerMypy, TypecheckerPyright, TypecheckerTy)
+	case reflect.TypeFor[Runner]():
+		return enum(RunnerMake, RunnerSageCi)
+	case reflect.TypeFor[*int]():
+		// StaleConfig days, where -1 means never.
+		return map[string]any{"type": "integer", "minimum": -1}
+	case reflect.TypeFor[SkipTargets]():
+		return map[string]any{
+			"type": "object",
+			"propertyNames": map[string]any{
+				"anyOf": []any{
+					map[string]any{"enum": g.targetNames},
+					// Target name patterns, see MatchPattern.
+					map[string]any{"pattern": `^re:|[*?\[{]`},
+				},
+			},
+			"additionalProperties": g.value(t.Elem()),
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": g.value(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.value(t.Elem())}
+	case reflect.Struct:
+		name := kebabCase(t.Name())
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = g.object(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}
+	default:
+		panic(fmt.Sprintf("sage-ci: no JSON Schema for config type %s", t))
+	}
+}
+
+// moduleEntry returns the schema for a structured module list entry:
+// a module path with the module's ModuleOptions.
+func (g schemaGenerator) moduleEntry() map[string]any {
+	const name = "module-entry"
+	if _, ok := g.defs[name]; !ok {
+		entry := g.object(reflect.TypeFor[ModuleOptions]())
+		entry["properties"].(map[string]any)["path"] = map[string]any{
+			"type":        "string",
+			"description": "Module path relative to the repository root.",
+		}
+		entry["required"] = []string{"path"}
+		g.defs[name] = entry
+	}
+	return map[string]any{"$ref": "#/$defs/" + name}
+}
+
+// enum returns a string schema limited to the given values.
+func enum[T ~string](values ...T) map[string]any {
+	return map[string]any{"type": "string", "enum": values}
+}
+
+// fieldDocs returns the doc comments of the types and struct fields in config.go,
+// keyed by "Type" and "Type.Field".
+func fieldDocs() (map[string]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "config.go", configSource, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parse config source: %w", err)
+	}
+	docs := map[string]string{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		decl, ok := n.(*ast.GenDecl)
+		if !ok || decl.Tok != token.TYPE {
+			return true
+		}
+		for _, spec := range decl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			docs[typeSpec.Name.Name] = strings.TrimSpace(decl.Doc.Text())
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			for _, field := range structType.Fields.List {
+				for _, name := range field.Names {
+					docs[typeSpec.Name.Name+"."+name.Name] = strings.TrimSpace(field.Doc.Text())
+				}
+			}
+		}
+		return false
+	})
+	return docs, nil
+}
+
+// kebabCase converts a Go identifier such as "GoTestOptions" to "go-test-options".
+func kebabCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('-')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToLower(b.String())
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Validate checks the config for problems and reports all of them at once.
+// Module paths are resolved relative to root.
+// SkipTargets keys must match at least one of knownTargets and SkipWorkflows entries at least one of
+// knownWorkflows; pass nil to skip either check.
+func (c Config) Validate(root string, knownTargets, knownWorkflows []string) error {
+	var errs []error
+	addf := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	for _, m := range []struct {
+		field    string
+		modules  []string
+		manifest string
+	}{
+		{field: "GoModules", modules: c.GoModules, manifest: "go.mod"},
+		{field: "PythonModules", modules: c.PythonModules, manifest: "pyproject.toml"},
+		{field: "LuaModules", modules: c.LuaModules},
+		{field: "TerraformModules", modules: c.TerraformModules, manifest: "*.tf"},
+	} {
+		for _, module := range m.modules {
+			if err := checkModule(root, module, m.manifest); err != nil {
+				addf("%s: %w", m.field, err)
+			}
+		}
+	}
+
+	for _, platform := range c.Platforms {
+		switch platform {
+		case PlatformGitHub, PlatformGitLab, PlatformCodeberg:
+		default:
+			addf(
+				"Platforms: unknown platform %q, expected one of %q, %q or %q",
+				platform, PlatformGitHub, PlatformGitLab, PlatformCodeberg,
+			)
+		}
+	}
+
+	switch c.Runner {
+	case "", RunnerMake, RunnerSageCi:
+	default:
+		addf("Runner: unknown runner %q, expected %q or %q", c.Runner, RunnerMake, RunnerSageCi)
+	}
+
+	for _, target := range sortedKeys(c.SkipTargets) {
+		if err := ValidatePattern(target); err != nil {
+			addf("SkipTargets: %w", err)
+		} else if knownTargets != nil && !matchesAnyName(target, knownTargets) {
+			if isPattern(target) {
+				addf("SkipTargets: pattern %q matches no target", target)
+			} else {
+				addf("SkipTargets: unknown target %q%s", target, suggest(target, knownTargets))
+			}
+		}
+		for _, module := range c.SkipTargets[target] {
+			if err := ValidatePattern(module); err != nil {
+				addf("SkipTargets[%q]: %w", target, err)
+			}
+		}
+	}
+	for _, workflow := range c.SkipWorkflows {
+		if err := ValidatePattern(workflow); err != nil {
+			addf("SkipWorkflows: %w", err)
+		} else if knownWorkflows != nil && !matchesAnyName(workflow, knownWorkflows) {
+			if isPattern(workflow) {
+				addf("SkipWorkflows: pattern %q matches no workflow", workflow)
+			} else {
+				addf("SkipWorkflows: unknown workflow %q%s", workflow, suggest(workflow, knownWorkflows))
+			}
+		}
+	}
+
+	for _, module := range sortedKeys(c.GoTestOptions) {
+		if module != "*" && !slices.Contains(c.GoModules, module) {
+			addf("GoTestOptions: %q is not one of the GoModules, use a module path or \"*\"", module)
+		}
+	}
+	for _, module := range sortedKeys(c.PythonOptions) {
+		if module != "*" && !slices.Contains(c.PythonModules, module) {
+			addf("PythonOptions: %q is not one of the PythonModules, use a module path or \"*\"", module)
+		}
+		switch checker := c.PythonOptions[module].Typechecker; checker {
+		case "", TypecheckerMypy, TypecheckerPyright, TypecheckerTy:
+		default:
+			addf(
+				"PythonOptions[%q]: unknown typechecker %q, expected one of %q, %q or %q",
+				module, checker, TypecheckerMypy, TypecheckerPyright, TypecheckerTy,
+			)
+		}
+	}
+
+	modules := slices.Concat(c.GoModules, c.PythonModules, c.LuaModules, c.TerraformModules)
+	for _, module := range sortedKeys(c.ModuleOptions) {
+		if !slices.Contains(modules, module) {
+			addf("ModuleOptions: %q is not one of the configured modules%s", module, suggest(module, modules))
+		}
+		if knownTargets == nil {
+			continue
+		}
+		for _, target := range sortedKeys(c.ModuleOptions[module].Args) {
+			if !slices.Contains(knownTargets, target) {
+				addf("ModuleOptions[%q].Args: unknown target %q%s", module, target, suggest(target, knownTargets))
+			}
+		}
+	}
+
+	for _, platform := range c.GoPlatforms {
+		if goos, goarch, ok := strings.Cut(platform, "/"); !ok || goos == "" || goarch == "" {
+			addf("GoPlatforms: invalid platform %q, expected GOOS/GOARCH such as \"linux/amd64\"", platform)
+		}
+	}
+
+	for _, days := range []*int{c.Stale.DaysBeforeStale, c.Stale.DaysBeforeClose} {
+		if days != nil && *days < -1 {
+			addf("Stale: days must be -1 (never) or more, got %d", *days)
+		}
+	}
+
+	for _, schedule := range []struct{ field, cron string }{{"StaleCron", c.StaleCron}, {"SyncCron", c.SyncCron}} {
+		if schedule.cron != "" && len(strings.Fields(schedule.cron)) != 5 {
+			addf("%s: invalid cron schedule %q, expected 5 fields such as \"0 0 * * *\"", schedule.field, schedule.cron)
+		}
+	}
+
+	if _, err := ParseVersionConstraint(c.SageCiVersion); err != nil {
+		addf("SageCiVersion: %w", err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// matchesAnyName reports whether pattern matches any of names.
+func matchesAnyName(pattern string, names []string) bool {
+	for _, name := range names {
+		if MatchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkModule checks that module is a directory below root containing manifest.
+// The manifest may be a glob pattern; an empty manifest only checks the directory.
+func checkModule(root, module, manifest string) error {
+	dir := filepath.Join(root, module)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("module %q does not exist, paths are relative to the repository root", module)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("module %q is not a directory", module)
+	}
+	if manifest == "" {
+		return nil
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, manifest))
+	if err != nil {
+		return fmt.Errorf("module %q: %w", module, err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("module %q has no %s, point the path at the module root", module, manifest)
+	}
+	return nil
+}
+
+// suggest returns a "did you mean" hint for the candidate closest to name, if any is close enough.
+func suggest(name string, candidates []string) string {
+	best, bestDistance := "", len(name)/2+1
+	for _, candidate := range candidates {
+		if d := levenshtein(strings.ToLower(name), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
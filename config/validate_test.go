@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"go.mod":            "module example.com/m\n",
+		"py/pyproject.toml": "[project]\nname = \"py\"\n",
+		"lua/init.lua":      "",
+		"infra/main.tf":     "",
+		"nomanifest/README": "",
+		"notadir":           "",
+		"infra/empty/.keep": "",
+	} {
+		p := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	targets := []string{"GoLint", "GoTest", "PythonMypy"}
+	workflows := []string{"sage-ci-go-ci", "sage-ci-sync"}
+
+	for _, tt := range []struct {
+		name string
+		cfg  Config
+		want []string
+	}{
+		{
+			name: "valid",
+			cfg: Config{
+				GoModules:        []string{"."},
+				PythonModules:    []string{"py"},
+				LuaModules:       []string{"lua"},
+				TerraformModules: []string{"infra"},
+				Platforms:        []Platform{PlatformGitHub},
+				SkipTargets:      SkipTargets{"GoLint": {"*"}},
+				SkipWorkflows:    []string{"sage-ci-sync"},
+				GoTestOptions:    map[string]GoTestOptions{"*": {NoRace: true}},
+				PythonOptions:    map[string]PythonOptions{"py": {Typechecker: TypecheckerTy}},
+				GoPlatforms:      []string{"linux/amd64"},
+				ModuleOptions: map[string]ModuleOptions{
+					"py": {PythonVersions: []string{"3.11"}, Args: map[string][]string{"PythonMypy": {"--strict"}}},
+				},
+			},
+		},
+		{
+			name: "missing modules and manifests",
+			cfg: Config{
+				GoModules:        []string{"nomanifest", "missing"},
+				PythonModules:    []string{"notadir"},
+				TerraformModules: []string{"infra/empty"},
+			},
+			want: []string{
+				`GoModules: module "nomanifest" has no go.mod`,
+				`GoModules: module "missing" does not exist`,
+				`PythonModules: module "notadir" is not a directory`,
+				`TerraformModules: module "infra/empty" has no *.tf`,
+			},
+		},
+		{
+			name: "unknown names",
+			cfg: Config{
+				Platforms:     []Platform{"gitub"},
+				Runner:        "just",
+				SkipTargets:   SkipTargets{"GoTests": {"*"}, "Nope": {"*"}, "Lua*": {"*"}, "Go*": {"["}},
+				SkipWorkflows: []string{"sage-ci-go", "sage-ci-*-ci", "re:("},
+			},
+			want: []string{
+				`Platforms: unknown platform "gitub"`,
+				`Runner: unknown runner "just"`,
+				`SkipTargets["Go*"]: invalid glob pattern "["`,
+				`SkipTargets: unknown target "GoTests" (did you mean "GoTest"?)`,
+				`SkipTargets: pattern "Lua*" matches no target`,
+				`SkipTargets: unknown target "Nope"`,
+				`SkipWorkflows: unknown workflow "sage-ci-go" (did you mean "sage-ci-go-ci"?)`,
+				`SkipWorkflows: invalid regular expression "re:("`,
+			},
+		},
+		{
+			name: "options",
+			cfg: Config{
+				GoModules:     []string{"."},
+				GoTestOptions: map[string]GoTestOptions{"tools": {}},
+				PythonOptions: map[string]PythonOptions{"*": {Typechecker: "pyre"}},
+				GoPlatforms:   []string{"linux"},
+				SyncCron:      "monthly",
+				SageCiVersion: "~v0.x",
+				Stale:         StaleConfig{DaysBeforeClose: Days(-2)},
+				ModuleOptions: map[string]ModuleOptions{
+					".":    {Args: map[string][]string{"GoTests": {"-short"}}},
+					"tool": {},
+				},
+			},
+			want: []string{
+				`GoTestOptions: "tools" is not one of the GoModules`,
+				`PythonOptions["*"]: unknown typechecker "pyre"`,
+				`ModuleOptions["."].Args: unknown target "GoTests" (did you mean "GoTest"?)`,
+				`ModuleOptions: "tool" is not one of the configured modules`,
+				`GoPlatforms: invalid platform "linux"`,
+				`Stale: days must be -1 (never) or more, got -2`,
+				`SyncCron: invalid cron schedule "monthly"`,
+				`SageCiVersion: invalid version constraint "~v0.x"`,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate(root, targets, workflows)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want %d errors", len(tt.want))
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("Validate() reported %d errors, want %d:\n%v", len(lines), len(tt.want), err)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("error %d = %q, want prefix %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version such as "v1.2.3" or "v1.2.3-rc.1".
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          string
+}
+
+// ParseVersion parses a semantic version, with or without the "v" prefix.
+// Build metadata is ignored.
+func ParseVersion(s string) (Version, error) {
+	v, parts, err := parsePartialVersion(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if parts != 3 {
+		return Version{}, fmt.Errorf("invalid version %q, expected major.minor.patch", s)
+	}
+	return v, nil
+}
+
+// parsePartialVersion parses a version with one to three numeric parts, such as "v1" or "1.2",
+// returning how many parts were given. Only complete versions may have a prerelease.
+func parsePartialVersion(s string) (Version, int, error) {
+	rest := strings.TrimPrefix(s, "v")
+	rest, _, _ = strings.Cut(rest, "+")
+	rest, prerelease, hasPrerelease := strings.Cut(rest, "-")
+	fields := strings.Split(rest, ".")
+	if len(fields) > 3 || (hasPrerelease && (len(fields) != 3 || prerelease == "")) {
+		return Version{}, 0, fmt.Errorf("invalid version %q", s)
+	}
+	var numbers [3]int
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 || field != strconv.Itoa(n) {
+			return Version{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+		numbers[i] = n
+	}
+	v := Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2], Prerelease: prerelease}
+	return v, len(fields), nil
+}
+
+// String returns the version with the "v" prefix Go modules use.
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is lower than, equal to or higher than w.
+// A prerelease is lower than the release of the same version.
+func (v Version) Compare(w Version) int {
+	c := cmp.Or(cmp.Compare(v.Major, w.Major), cmp.Compare(v.Minor, w.Minor), cmp.Compare(v.Patch, w.Patch))
+	if c != 0 {
+		return c
+	}
+	switch {
+	case v.Prerelease == w.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case w.Prerelease == "":
+		return -1
+	}
+	return comparePrerelease(v.Prerelease, w.Prerelease)
+}
+
+// comparePrerelease compares dot-separated prerelease identifiers, numeric identifiers numerically.
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range min(len(as), len(bs)) {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = cmp.Compare(an, bn)
+		case aErr == nil:
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(as[i], bs[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// VersionConstraint selects versions of a module. It is parsed from one of:
+//   - "latest": the highest release.
+//   - "v1.2.3": exactly that version, which may be a prerelease.
+//   - "v1" or "v1.2": the highest release of a major or minor line.
+//   - "~1.2.3": at least 1.2.3 within the 1.2 minor line.
+//   - "^1.2.3": at least 1.2.3 within the 1 major line, or the 0.2 minor line for 0.x versions.
+type VersionConstraint struct {
+	raw string
+	// exact is set for constraints naming a single version.
+	exact bool
+	// lower is the lowest allowed version, upper the lowest version above the allowed range.
+	// A zero upper means no upper bound.
+	lower, upper Version
+}
+
+// ParseVersionConstraint parses a VersionConstraint. An empty string means "latest".
+func ParseVersionConstraint(s string) (VersionConstraint, error) {
+	c := VersionConstraint{raw: s}
+	if s == "" || s == "latest" {
+		c.raw = "latest"
+		return c, nil
+	}
+	operator, rest := byte(0), s
+	if s[0] == '~' || s[0] == '^' {
+		operator, rest = s[0], s[1:]
+	}
+	v, parts, err := parsePartialVersion(rest)
+	if err != nil {
+		return VersionConstraint{}, fmt.Errorf(
+			"invalid version constraint %q, expected e.g. latest, v1.2.3, v1.2, ~1.2.3 or ^1.2.3", s,
+		)
+	}
+	c.lower = v
+	switch {
+	case operator == 0 && parts == 3:
+		c.exact = true
+	case operator == '^' && (v.Major > 0 || parts == 1):
+		c.upper = Version{Major: v.Major + 1}
+	case operator == '^' && (v.Minor > 0 || parts == 2):
+		c.upper = Version{Minor: v.Minor + 1}
+	case operator == '^':
+		c.upper = Version{Patch: v.Patch + 1}
+	case parts == 1:
+		c.upper = Version{Major: v.Major + 1}
+	default:
+		c.upper = Version{Major: v.Major, Minor: v.Minor + 1}
+	}
+	return c, nil
+}
+
+// String returns the constraint as it was written.
+func (c VersionConstraint) String() string {
+	return c.raw
+}
+
+// Exact reports whether the constraint names a single version.
+func (c VersionConstraint) Exact() bool {
+	return c.exact
+}
+
+// Allows reports whether v satisfies the constraint.
+// Prereleases are only allowed by exact constraints.
+func (c VersionConstraint) Allows(v Version) bool {
+	if c.exact {
+		return v.Compare(c.lower) == 0
+	}
+	if v.Prerelease != "" || v.Compare(c.lower) < 0 {
+		return false
+	}
+	return c.upper == (Version{}) || v.Compare(c.upper) < 0
+}
+
+// Latest returns the highest of versions allowed by the constraint.
+// Versions which aren't semantic versions are ignored.
+func (c VersionConstraint) Latest(versions []string) (string, bool) {
+	var latest Version
+	var found bool
+	for _, s := range versions {
+		v, err := ParseVersion(s)
+		if err != nil || !c.Allows(v) {
+			continue
+		}
+		if !found || v.Compare(latest) > 0 {
+			latest, found = v, true
+		}
+	}
+	if !found {
+		return "", false
+	}
+	return latest.String(), true
+}
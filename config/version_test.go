@@ -0,0 +1,61 @@
+package config
+
+import "testing"
+
+func TestVersionConstraint(t *testing.T) {
+	versions := []string{"v0.3.0", "v0.4.0", "v0.4.2", "v0.5.0-rc.1", "v0.5.0", "v0.5.1", "v1.0.0", "v1.2.0", "bad"}
+	for _, tt := range []struct {
+		constraint string
+		want       string
+	}{
+		{constraint: "", want: "v1.2.0"},
+		{constraint: "latest", want: "v1.2.0"},
+		{constraint: "v0.4", want: "v0.4.2"},
+		{constraint: "0", want: "v0.5.1"},
+		{constraint: "v0.5.0-rc.1", want: "v0.5.0-rc.1"},
+		{constraint: "~0.4.1", want: "v0.4.2"},
+		{constraint: "~0.4.3"},
+		{constraint: "^0.4.0", want: "v0.4.2"},
+		{constraint: "^0.5", want: "v0.5.1"},
+		{constraint: "^1.0.0", want: "v1.2.0"},
+		{constraint: "v2"},
+	} {
+		c, err := ParseVersionConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseVersionConstraint(%q) failed: %v", tt.constraint, err)
+		}
+		if got, _ := c.Latest(versions); got != tt.want {
+			t.Errorf("ParseVersionConstraint(%q).Latest() = %q, want %q", tt.constraint, got, tt.want)
+		}
+	}
+	for _, constraint := range []string{"v1.2.3.4", ">=1.0.0", "~", "v1.x", "1.2-rc.1", "01.2.3"} {
+		if _, err := ParseVersionConstraint(constraint); err == nil {
+			t.Errorf("ParseVersionConstraint(%q) succeeded, want error", constraint)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{a: "v1.2.3", b: "1.2.3", want: 0},
+		{a: "v1.10.0", b: "v1.9.0", want: 1},
+		{a: "v1.0.0-rc.1", b: "v1.0.0", want: -1},
+		{a: "v1.0.0-rc.2", b: "v1.0.0-rc.10", want: -1},
+		{a: "v1.0.0-beta", b: "v1.0.0-alpha.1", want: 1},
+	} {
+		a, err := ParseVersion(tt.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseVersion(tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -3,3 +3,9 @@ module github.com/fredrikaverpil/sage-ci
 go 1.25.5
 
 require go.einride.tech/sage v0.391.1
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/bmatcuk/doublestar/v4 v4.10.0
+	gopkg.in/yaml.v3 v3.0.1
+)
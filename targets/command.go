@@ -0,0 +1,105 @@
+package targets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"go.einride.tech/sage/sg"
+)
+
+// command is a sage-ci CLI command which needs the configuration in .sage.
+type command struct {
+	args []string
+	run  func(ctx context.Context, cfg config.Config, args []string) error
+}
+
+// commands are the commands run by RunCommand, by name.
+var commands = map[string]command{
+	"ExplainConfig": {
+		args: []string{"format"},
+		run: func(ctx context.Context, cfg config.Config, args []string) error {
+			return ExplainConfig(ctx, cfg, args[0])
+		},
+	},
+	"ListSageCi": {
+		args: []string{"view", "format"},
+		run: func(ctx context.Context, cfg config.Config, args []string) error {
+			return ListSageCi(ctx, cfg, args[0], args[1])
+		},
+	},
+	"Doctor": {
+		run: func(ctx context.Context, cfg config.Config, _ []string) error {
+			return Doctor(ctx, cfg)
+		},
+	},
+	"UpgradeSageCi": {
+		args: []string{"version"},
+		run: func(ctx context.Context, cfg config.Config, args []string) error {
+			return UpgradeSageCi(ctx, cfg, args[0])
+		},
+	},
+	"PreviewUpgradeSageCi": {
+		args: []string{"version"},
+		run: func(ctx context.Context, cfg config.Config, args []string) error {
+			return PreviewUpgradeSageCi(ctx, cfg, args[0])
+		},
+	},
+	"RollbackSageCi": {
+		run: func(ctx context.Context, cfg config.Config, _ []string) error {
+			return RollbackSageCi(ctx, cfg)
+		},
+	},
+	"VendorTools": {
+		args: []string{"dir", "goos", "goarch"},
+		run: func(ctx context.Context, _ config.Config, args []string) error {
+			return VendorTools(ctx, args[0], args[1], args[2])
+		},
+	},
+	"EjectSageCi": {
+		args: []string{"workflows", "targets-file", "inline"},
+		run: func(ctx context.Context, cfg config.Config, args []string) error {
+			targetsFile, err := strconv.ParseBool(args[1])
+			if err != nil {
+				return fmt.Errorf("targets-file: %w", err)
+			}
+			inline, err := strconv.ParseBool(args[2])
+			if err != nil {
+				return fmt.Errorf("inline: %w", err)
+			}
+			return EjectSageCi(ctx, cfg, args[0], targetsFile, inline)
+		},
+	},
+}
+
+// RunCommand runs the sage-ci CLI command named args[0], such as "ExplainConfig", with the arguments
+// args[1:] and returns the exit code. The CLI builds the .sage package with an init function calling
+// RunCommand with the sagefile's cfg, so that its commands need no targets in the sagefile or Makefile.
+func RunCommand(cfg config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "expected a sage-ci command")
+		return 2
+	}
+	name, args := args[0], args[1:]
+	logger := sg.NewLogger(name)
+	c, ok := commands[name]
+	if !ok {
+		logger.Printf("unknown sage-ci command %s, sage-ci in .sage/go.mod may be older than the sage-ci CLI", name)
+		return 2
+	}
+	if len(args) != len(c.args) {
+		logger.Printf("wrong number of arguments to %s, got %d, expected %v", name, len(args), c.args)
+		return 2
+	}
+	ctx, stop := signal.NotifyContext(sg.WithLogger(context.Background(), logger), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := c.run(ctx, cfg, args); err != nil {
+		logger.Println(err)
+		return 1
+	}
+	return 0
+}
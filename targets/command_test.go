@@ -0,0 +1,31 @@
+package targets
+
+import (
+	"testing"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+)
+
+func TestRunCommand_usage(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		args []string
+	}{
+		{"no command", nil},
+		{"unknown command", []string{"Nope"}},
+		{"missing argument", []string{"ExplainConfig"}},
+		{"extra argument", []string{"Doctor", "json"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RunCommand(config.Config{}, tt.args); got != 2 {
+				t.Errorf("RunCommand(%q) = %d, want 2", tt.args, got)
+			}
+		})
+	}
+}
+
+func TestRunCommand_error(t *testing.T) {
+	if got := RunCommand(config.Config{}, []string{"ExplainConfig", "xml"}); got != 1 {
+		t.Errorf("RunCommand(ExplainConfig xml) = %d, want 1", got)
+	}
+}
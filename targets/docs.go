@@ -0,0 +1,55 @@
+package targets
+
+import (
+	"context"
+	"os"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"github.com/fredrikaverpil/sage-ci/tools/sgmdformat"
+	"github.com/fredrikaverpil/sage-ci/tools/sgyamllint"
+	"go.einride.tech/sage/sg"
+)
+
+// MarkdownFormat runs mdformat for all configured Markdown files.
+// In CI, formatting is only checked and the target fails on unformatted files.
+func MarkdownFormat(ctx context.Context, cfg config.Config) error {
+	files, err := docsFiles(ctx, cfg, "MarkdownFormat", cfg.MarkdownFiles)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	args := []string{"--wrap", "80", "--number"}
+	if os.Getenv("CI") != "" {
+		sg.Logger(ctx).Printf("checking mdformat for %d file(s)...", len(files))
+		args = append(args, "--check")
+	} else {
+		sg.Logger(ctx).Printf("applying mdformat to %d file(s)...", len(files))
+	}
+	cmd := sgmdformat.Command(ctx, append(args, files...)...)
+	cmd.Dir = sg.FromGitRoot()
+	return cmd.Run()
+}
+
+// YamlLint runs yamllint for all configured YAML files.
+func YamlLint(ctx context.Context, cfg config.Config) error {
+	files, err := docsFiles(ctx, cfg, "YamlLint", cfg.YAMLFiles)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	sg.Logger(ctx).Printf("running yamllint on %d file(s)...", len(files))
+	args := append(sgyamllint.ConfigArgs(), "--strict")
+	cmd := sgyamllint.Command(ctx, append(args, files...)...)
+	cmd.Dir = sg.FromGitRoot()
+	return cmd.Run()
+}
+
+// docsFiles returns the documentation files matching patterns, minus DocsExclude.
+func docsFiles(ctx context.Context, cfg config.Config, target string, patterns []string) ([]string, error) {
+	cfg = cfg.WithDefaults()
+	return matchFiles(ctx, cfg, target, patterns, cfg.DocsExclude)
+}
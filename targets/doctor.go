@@ -0,0 +1,393 @@
+package targets
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/version"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"github.com/fredrikaverpil/sage-ci/workflows/github"
+	"go.einride.tech/sage/sg"
+)
+
+// CheckStatus is the outcome of a Doctor check.
+type CheckStatus string
+
+const (
+	// CheckPass means the check found no problem.
+	CheckPass CheckStatus = "pass"
+	// CheckWarn means the check found something which may cause problems.
+	CheckWarn CheckStatus = "warn"
+	// CheckFail means the check found something which breaks targets or workflows.
+	CheckFail CheckStatus = "fail"
+)
+
+// Check is the result of a Doctor check.
+type Check struct {
+	Name    string
+	Status  CheckStatus
+	Message string
+	// Hint explains how to fix a warning or failure.
+	Hint string
+}
+
+// WriteChecks writes checks as one line per check, followed by the hint of warnings and failures.
+func WriteChecks(w io.Writer, checks []Check) error {
+	width := 0
+	for _, c := range checks {
+		width = max(width, len(c.Name))
+	}
+	for _, c := range checks {
+		status := strings.ToUpper(string(c.Status))
+		if _, err := fmt.Fprintf(w, "%-4s  %-*s  %s\n", status, width, c.Name, c.Message); err != nil {
+			return err
+		}
+		if c.Status != CheckPass && c.Hint != "" {
+			if _, err := fmt.Fprintf(w, "      %*s  hint: %s\n", width, "", c.Hint); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Doctor checks the toolchain, the .sage dependencies, generated files, the tool cache and
+// GitHub Actions permissions, and prints pass, warn or fail with a hint for each check.
+// It fails if any check fails.
+func Doctor(ctx context.Context, cfg config.Config) error {
+	checks := Diagnose(ctx, cfg.WithDefaults())
+	if err := WriteChecks(os.Stdout, checks); err != nil {
+		return err
+	}
+	var failed int
+	for _, c := range checks {
+		if c.Status == CheckFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// Diagnose runs the Doctor checks for cfg, which must have defaults applied.
+func Diagnose(ctx context.Context, cfg config.Config) []Check {
+	checks := []Check{
+		checkConfig(cfg),
+		checkGoVersion(ctx, cfg),
+		checkMake(),
+	}
+	if cfg.HasPython() {
+		checks = append(checks, checkTool("uv", "Python targets"))
+	}
+	checks = append(checks,
+		checkDependencies(ctx),
+		checkTargetsFile(cfg, sg.FromGitRoot(".sage")),
+	)
+	if slices.Contains(cfg.Platforms, config.PlatformGitHub) {
+		checks = append(checks,
+			checkWorkflows(cfg),
+			checkDefaultBranch(cfg, DetectDefaultBranch(sg.FromGitRoot())),
+			checkActionsPermissions(ctx, cfg),
+		)
+	}
+	return append(checks, checkToolCache(sg.FromSageDir("tools")))
+}
+
+func checkConfig(cfg config.Config) Check {
+	c := Check{Name: "config", Status: CheckPass, Message: "valid"}
+	if err := ValidateConfig(cfg); err != nil {
+		c.Status, c.Message = CheckFail, strings.ReplaceAll(err.Error(), "\n", "; ")
+		c.Hint = "fix the reported settings, see sage-ci config explain"
+	}
+	return c
+}
+
+// checkGoVersion compares the go command's version with the go directives of the Go modules.
+func checkGoVersion(ctx context.Context, cfg config.Config) Check {
+	c := Check{Name: "go"}
+	cmd := sg.Command(ctx, "go", "env", "GOVERSION")
+	cmd.Stdout = nil
+	output, err := cmd.Output()
+	if err != nil {
+		c.Status, c.Message = CheckFail, "go not found"
+		c.Hint = "install Go from https://go.dev/dl"
+		return c
+	}
+	goVersion := strings.TrimSpace(string(output))
+	c.Status, c.Message = CheckPass, goVersion
+	for _, module := range selectModules(cfg.GoModules) {
+		required := goDirective(sg.FromGitRoot(module, "go.mod"))
+		if required != "" && version.Compare(goVersion, "go"+required) < 0 {
+			c.Status = CheckWarn
+			c.Message = fmt.Sprintf("%s is older than go %s required by %s", goVersion, required, module)
+			c.Hint = "upgrade Go, or let GOTOOLCHAIN=auto download the required toolchain"
+		}
+	}
+	return c
+}
+
+// goDirective returns the version of the go directive in the go.mod file at path, if any.
+func goDirective(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "go "); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func checkMake() Check {
+	c := Check{Name: "make", Status: CheckPass, Message: "found"}
+	if _, err := exec.LookPath("make"); err != nil {
+		c.Status, c.Message = CheckWarn, "make not found in PATH"
+		c.Hint = "run targets with sage-ci run <target>, and set runner: sage-ci for workflows"
+	}
+	return c
+}
+
+// checkTool reports whether a tool downloaded on demand by sage is cached or in PATH.
+func checkTool(name, usedBy string) Check {
+	c := Check{Name: name, Status: CheckPass}
+	if _, err := os.Stat(sg.FromSageDir("tools", name)); err == nil {
+		c.Message = "cached in .sage/tools"
+		return c
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		c.Message = "found at " + path
+		return c
+	}
+	c.Status, c.Message = CheckWarn, fmt.Sprintf("not downloaded yet, %s download it on first use", usedBy)
+	c.Hint = fmt.Sprintf("run one of the %s with network access", usedBy)
+	return c
+}
+
+// checkDependencies reports available updates of sage and sage-ci in .sage/go.mod.
+func checkDependencies(ctx context.Context) Check {
+	c := Check{Name: "dependencies"}
+	cmd := sg.Command(
+		ctx, "go", "list", "-m", "-u", "-json", "go.einride.tech/sage", "github.com/fredrikaverpil/sage-ci",
+	)
+	cmd.Dir = sg.FromGitRoot(".sage")
+	cmd.Stdout = nil
+	output, err := cmd.Output()
+	if err != nil {
+		c.Status, c.Message = CheckWarn, fmt.Sprintf("could not check for updates: %v", err)
+		c.Hint = "check network access to the Go module proxy"
+		return c
+	}
+	var current, outdated []string
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for decoder.More() {
+		var module struct {
+			Path    string
+			Version string
+			Main    bool
+			Update  *struct{ Version string }
+			Replace *struct{ Path string }
+		}
+		if err := decoder.Decode(&module); err != nil {
+			c.Status, c.Message = CheckWarn, fmt.Sprintf("could not parse go list output: %v", err)
+			return c
+		}
+		switch {
+		case module.Main:
+			current = append(current, module.Path+" (main module)")
+		case module.Replace != nil:
+			current = append(current, fmt.Sprintf("%s => %s", module.Path, module.Replace.Path))
+		case module.Update != nil:
+			outdated = append(outdated, fmt.Sprintf("%s %s (latest %s)", module.Path, module.Version, module.Update.Version))
+		default:
+			current = append(current, module.Path+" "+module.Version)
+		}
+	}
+	if len(outdated) > 0 {
+		c.Status, c.Message = CheckWarn, strings.Join(outdated, ", ")
+		c.Hint = "run make update-sage and make update-sage-ci"
+		return c
+	}
+	c.Status, c.Message = CheckPass, strings.Join(current, ", ")
+	return c
+}
+
+// checkTargetsFile compares targets.gen.go in dir with the file generated for cfg.
+func checkTargetsFile(cfg config.Config, dir string) Check {
+	c := Check{Name: "targets.gen.go", Hint: "run make update-sage-ci"}
+	if cfg.SkipTargetsFile {
+		c.Status, c.Message = CheckPass, "not generated, SkipTargetsFile is set"
+		return c
+	}
+	tmpDir, err := os.MkdirTemp("", "sage-ci-targets-*")
+	if err != nil {
+		c.Status, c.Message = CheckWarn, fmt.Sprintf("could not generate: %v", err)
+		return c
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	if err := GenerateTargetsFile(cfg, tmpDir); err != nil {
+		c.Status, c.Message = CheckWarn, fmt.Sprintf("could not generate: %v", err)
+		return c
+	}
+	want, wantErr := os.ReadFile(filepath.Join(tmpDir, "targets.gen.go"))
+	got, gotErr := os.ReadFile(filepath.Join(dir, "targets.gen.go"))
+	switch {
+	case os.IsNotExist(wantErr) && os.IsNotExist(gotErr):
+		c.Status, c.Message = CheckPass, "no targets configured"
+	case os.IsNotExist(gotErr):
+		c.Status, c.Message = CheckFail, "missing"
+	case os.IsNotExist(wantErr):
+		c.Status, c.Message = CheckFail, "exists, but no targets are configured"
+	case wantErr != nil || gotErr != nil:
+		c.Status, c.Message = CheckWarn, fmt.Sprintf("could not compare: %v", cmp.Or(wantErr, gotErr))
+	case !bytes.Equal(got, want):
+		c.Status, c.Message = CheckFail, "out of sync with the config"
+	default:
+		c.Status, c.Message = CheckPass, "in sync with the config"
+	}
+	return c
+}
+
+func checkWorkflows(cfg config.Config) Check {
+	c := Check{Name: "workflows", Hint: "run make generate-workflows"}
+	stale, err := github.StaleWorkflows(cfg)
+	switch {
+	case err != nil:
+		c.Status, c.Message = CheckWarn, fmt.Sprintf("could not generate: %v", err)
+	case len(stale) > 0:
+		c.Status, c.Message = CheckFail, "stale: "+strings.Join(stale, ", ")
+	default:
+		c.Status, c.Message = CheckPass, "up to date"
+	}
+	return c
+}
+
+// checkDefaultBranch compares the configured DefaultBranch with the detected one, if any.
+func checkDefaultBranch(cfg config.Config, detected string) Check {
+	c := Check{Name: "default branch", Hint: fmt.Sprintf("set default-branch to %q", detected)}
+	switch {
+	case cfg.DefaultBranch == "" && detected == "":
+		c.Status, c.Message = CheckWarn, "not configured and could not be detected, CI runs on main"
+		c.Hint = "set default-branch to the branch CI should run on"
+	case detected == "":
+		c.Status, c.Message = CheckPass, cfg.DefaultBranch+" (could not compare with origin/HEAD)"
+	case cfg.DefaultBranch == "" && detected == "main":
+		c.Status, c.Message = CheckPass, "main (default)"
+	case cfg.DefaultBranch == "":
+		c.Status, c.Message = CheckWarn, fmt.Sprintf("not configured, CI runs on main but origin/HEAD is %s", detected)
+	case cfg.DefaultBranch != detected:
+		c.Status, c.Message = CheckWarn, fmt.Sprintf("%s is configured but origin/HEAD is %s", cfg.DefaultBranch, detected)
+	default:
+		c.Status, c.Message = CheckPass, cfg.DefaultBranch
+	}
+	return c
+}
+
+// checkActionsPermissions checks the repository's GitHub Actions settings with the gh CLI.
+// The release and sync workflows create branches and pull requests, which requires write permissions.
+func checkActionsPermissions(ctx context.Context, cfg config.Config) Check {
+	c := Check{
+		Name: "actions permissions",
+		Hint: "in Settings → Actions → General, select Read and write permissions " +
+			"and allow GitHub Actions to create and approve pull requests",
+	}
+	if cfg.ShouldSkipWorkflow("sage-ci-release") && cfg.ShouldSkipWorkflow("sage-ci-sync") {
+		c.Status, c.Message = CheckPass, "not needed, release and sync workflows are skipped"
+		return c
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		c.Status, c.Message = CheckWarn, "could not check, gh not found in PATH"
+		c.Hint = "install and authenticate the gh CLI, or check the settings manually: " + c.Hint
+		return c
+	}
+	cmd := sg.Command(ctx, "gh", "api", "repos/{owner}/{repo}/actions/permissions/workflow")
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+	output, err := cmd.Output()
+	if err != nil {
+		c.Status, c.Message = CheckWarn, fmt.Sprintf("could not check: %v", err)
+		c.Hint = "authenticate with gh auth login, or check the settings manually: " + c.Hint
+		return c
+	}
+	var permissions struct {
+		DefaultWorkflowPermissions   string `json:"default_workflow_permissions"`
+		CanApprovePullRequestReviews bool   `json:"can_approve_pull_request_reviews"`
+	}
+	if err := json.Unmarshal(output, &permissions); err != nil {
+		c.Status, c.Message = CheckWarn, fmt.Sprintf("could not parse gh output: %v", err)
+		return c
+	}
+	if permissions.DefaultWorkflowPermissions != "write" || !permissions.CanApprovePullRequestReviews {
+		c.Status = CheckFail
+		c.Message = fmt.Sprintf(
+			"workflow permissions are %q and creating pull requests is %s",
+			permissions.DefaultWorkflowPermissions, allowed(permissions.CanApprovePullRequestReviews),
+		)
+		return c
+	}
+	c.Status, c.Message = CheckPass, "workflows can write and create pull requests"
+	return c
+}
+
+func allowed(ok bool) string {
+	if ok {
+		return "allowed"
+	}
+	return "not allowed"
+}
+
+// checkToolCache reports the tools cached in dir, warning about tools with several cached versions.
+func checkToolCache(dir string) Check {
+	c := Check{Name: "tool cache", Hint: "run make clean-sage to remove old versions"}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		c.Status, c.Message = CheckPass, "empty, tools are downloaded on first use"
+		return c
+	} else if err != nil {
+		c.Status, c.Message = CheckWarn, fmt.Sprintf("could not read %s: %v", dir, err)
+		return c
+	}
+	var tools, outdated []string
+	var size int64
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		tools = append(tools, entry.Name())
+		versions, err := os.ReadDir(filepath.Join(dir, entry.Name()))
+		if err == nil && len(versions) > 1 {
+			outdated = append(outdated, fmt.Sprintf("%s (%d versions)", entry.Name(), len(versions)))
+		}
+	}
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	c.Status = CheckPass
+	c.Message = fmt.Sprintf("%d tools, %d MB", len(tools), size>>20)
+	if len(outdated) > 0 {
+		c.Status = CheckWarn
+		c.Message += ", several versions of " + strings.Join(outdated, ", ")
+	}
+	return c
+}
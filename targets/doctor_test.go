@@ -0,0 +1,89 @@
+package targets
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+)
+
+func TestCheckTargetsFile(t *testing.T) {
+	cfg := config.Config{GoModules: []string{"."}}.WithDefaults()
+	dir := t.TempDir()
+	if c := checkTargetsFile(cfg, dir); c.Status != CheckFail || c.Message != "missing" {
+		t.Errorf("checkTargetsFile() without targets.gen.go = %+v, want fail missing", c)
+	}
+	if err := GenerateTargetsFile(cfg, dir); err != nil {
+		t.Fatal(err)
+	}
+	if c := checkTargetsFile(cfg, dir); c.Status != CheckPass {
+		t.Errorf("checkTargetsFile() after generating = %+v, want pass", c)
+	}
+	cfg.PythonModules = []string{"py"}
+	if c := checkTargetsFile(cfg, dir); c.Status != CheckFail || c.Hint == "" {
+		t.Errorf("checkTargetsFile() after adding Python modules = %+v, want fail with hint", c)
+	}
+	cfg.SkipTargetsFile = true
+	if c := checkTargetsFile(cfg, t.TempDir()); c.Status != CheckPass {
+		t.Errorf("checkTargetsFile() with SkipTargetsFile = %+v, want pass", c)
+	}
+}
+
+func TestCheckToolCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tools")
+	if c := checkToolCache(dir); c.Status != CheckPass {
+		t.Errorf("checkToolCache() without tools = %+v, want pass", c)
+	}
+	for _, path := range []string{"uv/0.6.12/bin/uv", "yamllint/1.0.0/bin/yamllint", "yamllint/1.1.0/bin/yamllint"} {
+		p := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := checkToolCache(dir)
+	if c.Status != CheckWarn || !strings.Contains(c.Message, "yamllint (2 versions)") {
+		t.Errorf("checkToolCache() with two yamllint versions = %+v, want warning about yamllint", c)
+	}
+}
+
+func TestWriteChecks(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteChecks(&buf, []Check{
+		{Name: "go", Status: CheckPass, Message: "go1.25.0", Hint: "not shown"},
+		{Name: "workflows", Status: CheckFail, Message: "stale: sage-ci-go", Hint: "run make generate-workflows"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "PASS  go         go1.25.0\n" +
+		"FAIL  workflows  stale: sage-ci-go\n" +
+		"                 hint: run make generate-workflows\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteChecks() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCheckDefaultBranch(t *testing.T) {
+	for _, tt := range []struct {
+		configured, detected string
+		want                 CheckStatus
+	}{
+		{"", "", CheckWarn},
+		{"", "main", CheckPass},
+		{"", "master", CheckWarn},
+		{"develop", "", CheckPass},
+		{"develop", "main", CheckWarn},
+		{"master", "master", CheckPass},
+	} {
+		c := checkDefaultBranch(config.Config{DefaultBranch: tt.configured}, tt.detected)
+		if c.Status != tt.want {
+			t.Errorf("checkDefaultBranch(%q, %q) = %+v, want status %v", tt.configured, tt.detected, c, tt.want)
+		}
+	}
+}
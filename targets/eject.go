@@ -0,0 +1,333 @@
+package targets
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"github.com/fredrikaverpil/sage-ci/workflows/github"
+	"go.einride.tech/sage/sg"
+)
+
+// EjectSageCi renders generated files one final time as project-owned files, and configures sage-ci
+// to stop generating them:
+//   - workflows is a comma-separated list of workflow names, "all" or "none". The ejected workflows
+//     lose their "DO NOT EDIT" header and are added to SkipWorkflows.
+//   - With ejectTargets, targets.gen.go is moved to targets.go and SkipTargetsFile is set.
+//   - With inline, the targets package is also copied into .sage/targets, and the .sage sources
+//     import it instead of sage-ci's, so the targets can diverge from sage-ci.
+//
+// The configuration file is updated if there is one, otherwise the cfg literal in .sage/sagefile.go.
+func EjectSageCi(ctx context.Context, cfg config.Config, workflows string, ejectTargets, inline bool) error {
+	effective := cfg.WithDefaults()
+	if err := ValidateConfig(effective); err != nil {
+		return err
+	}
+	if (workflows != "none" && workflows != "") || ejectTargets || inline {
+		// Fail before ejecting anything if sage-ci can't be configured to stop generating the files.
+		if err := applySettings(ctx, nil); err != nil {
+			return err
+		}
+	}
+
+	var ejected []string
+	if workflows != "none" && workflows != "" {
+		if !slices.Contains(effective.Platforms, config.PlatformGitHub) {
+			return errors.New("ejecting workflows is only implemented for GitHub")
+		}
+		var names []string
+		if workflows != "all" {
+			for _, name := range strings.Split(workflows, ",") {
+				names = append(names, strings.TrimSuffix(strings.TrimSpace(name), ".yml"))
+			}
+		}
+		var err error
+		if ejected, err = github.Eject(effective, names); err != nil {
+			return err
+		}
+		for _, name := range ejected {
+			sg.Logger(ctx).Printf("ejected .github/workflows/%s.yml", name)
+		}
+	}
+
+	if ejectTargets || inline {
+		if effective.SkipTargetsFile {
+			return errors.New("targets are already ejected, SkipTargetsFile is set")
+		}
+		if err := ejectTargetsFile(ctx, effective, inline); err != nil {
+			return err
+		}
+	}
+
+	var settings []setting
+	if len(ejected) > 0 {
+		skip := slices.Clone(cfg.SkipWorkflows)
+		for _, name := range ejected {
+			if !cfg.ShouldSkipWorkflow(name) {
+				skip = append(skip, name)
+			}
+		}
+		settings = append(settings, setting{"skip-workflows", "SkipWorkflows", skip})
+	}
+	if ejectTargets || inline {
+		settings = append(settings, setting{"skip-targets-file", "SkipTargetsFile", true})
+	}
+	return applySettings(ctx, settings)
+}
+
+// setting is a configuration value to set, by file key and Go field name.
+type setting struct {
+	key, field string
+	value      any
+}
+
+// applySettings sets the settings in the configuration file, or else in the cfg literal in sagefile.go.
+// Without settings, it only checks that they can be set.
+func applySettings(ctx context.Context, settings []setting) error {
+	if path, ok := config.FindFile(sg.FromSageDir()); ok {
+		for _, s := range settings {
+			if err := config.SetFileValue(path, s.key, s.value); err != nil {
+				return err
+			}
+		}
+		if len(settings) > 0 {
+			sg.Logger(ctx).Printf("updated %s", filepath.Base(path))
+		}
+		return nil
+	}
+	path := sg.FromSageDir("sagefile.go")
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read sagefile.go: %w", err)
+	}
+	content, err := setSagefileFields(src, settings)
+	if err != nil {
+		return err
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("write sagefile.go: %w", err)
+	}
+	sg.Logger(ctx).Print("updated sagefile.go")
+	return nil
+}
+
+// setSagefileFields sets the fields of the settings in the config.Config literal assigned to cfg in
+// the sagefile source src, replacing their values if set and adding them otherwise.
+func setSagefileFields(src []byte, settings []setting) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "sagefile.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parse sagefile.go: %w", err)
+	}
+	lit := cfgLiteral(file)
+	if lit == nil {
+		return nil, errors.New("cannot set SkipWorkflows and SkipTargetsFile: " +
+			"cfg in .sage/sagefile.go is not a config.Config literal and there is no configuration file")
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	var added strings.Builder
+	for _, s := range settings {
+		i := slices.IndexFunc(lit.Elts, func(elt ast.Expr) bool {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				return false
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			return ok && key.Name == s.field
+		})
+		if i < 0 {
+			fmt.Fprintf(&added, "\t%s: %s,\n", s.field, goLiteral(s.value))
+			continue
+		}
+		value := lit.Elts[i].(*ast.KeyValueExpr).Value
+		edits = append(edits, edit{offset(value.Pos()), offset(value.End()), goLiteral(s.value)})
+	}
+	if added.Len() > 0 {
+		text := added.String()
+		// A literal on a single line, e.g. config.Config{GoModules: []string{"."}}, is split into lines.
+		line := func(pos token.Pos) int { return fset.Position(pos).Line }
+		if n := len(lit.Elts); n == 0 || line(lit.Elts[n-1].End()) == line(lit.Rbrace) {
+			text = "\n" + text
+			if n > 0 {
+				text = "," + text
+			}
+		}
+		if len(lit.Elts) > 0 && line(lit.Elts[0].Pos()) == line(lit.Lbrace) {
+			edits = append(edits, edit{offset(lit.Lbrace) + 1, offset(lit.Lbrace) + 1, "\n"})
+		}
+		edits = append(edits, edit{offset(lit.Rbrace), offset(lit.Rbrace), text})
+	}
+	// Apply the edits from the end, so that the offsets of the others stay valid.
+	slices.SortFunc(edits, func(a, b edit) int { return cmp.Compare(b.start, a.start) })
+	content := src
+	for _, e := range edits {
+		content = slices.Concat(content[:e.start], []byte(e.text), content[e.end:])
+	}
+	formatted, err := format.Source(content)
+	if err != nil {
+		return nil, fmt.Errorf("format sagefile.go: %w", err)
+	}
+	return formatted, nil
+}
+
+// cfgLiteral returns the config.Config composite literal assigned to the package-level variable cfg,
+// or nil if cfg is assigned something else.
+func cfgLiteral(file *ast.File) *ast.CompositeLit {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			for i, name := range valueSpec.Names {
+				if name.Name != "cfg" || i >= len(valueSpec.Values) {
+					continue
+				}
+				lit, ok := valueSpec.Values[i].(*ast.CompositeLit)
+				if !ok {
+					return nil
+				}
+				if sel, ok := lit.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "Config" {
+					return lit
+				}
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// goLiteral returns value, a bool or []string, as a Go literal.
+func goLiteral(value any) string {
+	list, ok := value.([]string)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		b, _ := json.Marshal(item)
+		items = append(items, string(b))
+	}
+	return "[]string{" + strings.Join(items, ", ") + "}"
+}
+
+// ejectTargetsFile replaces targets.gen.go with targets.go, which has no "DO NOT EDIT" header.
+// With inline, the targets package is copied into .sage/targets and imported from there.
+func ejectTargetsFile(ctx context.Context, cfg config.Config, inline bool) error {
+	content, err := renderTargetsFile(cfg)
+	if err != nil {
+		return err
+	}
+	if content == nil {
+		return errors.New("no targets are enabled, there is no targets.gen.go to eject")
+	}
+	content = bytes.TrimPrefix(content, []byte("// Code generated by sage-ci. DO NOT EDIT.\n\n"))
+	sageDir := sg.FromSageDir()
+	if err := os.WriteFile(filepath.Join(sageDir, "targets.go"), content, 0o644); err != nil {
+		return fmt.Errorf("write targets.go: %w", err)
+	}
+	if err := os.Remove(filepath.Join(sageDir, "targets.gen.go")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove targets.gen.go: %w", err)
+	}
+	sg.Logger(ctx).Print("ejected .sage/targets.gen.go to .sage/targets.go")
+	if inline {
+		return inlineTargetsPackage(ctx, sageDir)
+	}
+	return nil
+}
+
+// inlineTargetsPackage copies the sources of the targets package, at the sage-ci version in .sage/go.mod,
+// into sageDir/targets and rewrites the imports of the Go files in sageDir to use the copy.
+func inlineTargetsPackage(ctx context.Context, sageDir string) error {
+	output, err := goCommandOutput(ctx, "list", "-m", "-json", sageCiModule)
+	if err != nil {
+		return fmt.Errorf("locate sage-ci sources: %w", err)
+	}
+	var module struct{ Dir, Version string }
+	if err := json.Unmarshal(output, &module); err != nil {
+		return fmt.Errorf("parse go list output: %w", err)
+	}
+	sageModule, err := modulePath(filepath.Join(sageDir, "go.mod"))
+	if err != nil {
+		return err
+	}
+
+	sources, err := filepath.Glob(filepath.Join(module.Dir, "targets", "*.go"))
+	if err != nil {
+		return err
+	}
+	targetsDir := filepath.Join(sageDir, "targets")
+	if err := os.MkdirAll(targetsDir, 0o755); err != nil {
+		return fmt.Errorf("create .sage/targets: %w", err)
+	}
+	for _, source := range sources {
+		if strings.HasSuffix(source, "_test.go") {
+			continue
+		}
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", source, err)
+		}
+		// Module cache files are read-only, so the copies get their own permissions.
+		if err := os.WriteFile(filepath.Join(targetsDir, filepath.Base(source)), content, 0o644); err != nil {
+			return fmt.Errorf("copy %s: %w", filepath.Base(source), err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(sageDir, "*.go"))
+	if err != nil {
+		return err
+	}
+	oldImport := []byte(`"` + sageCiModule + `/targets"`)
+	newImport := []byte(`"` + sageModule + `/targets"`)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if rewritten := bytes.ReplaceAll(content, oldImport, newImport); !bytes.Equal(rewritten, content) {
+			if err := os.WriteFile(file, rewritten, 0o644); err != nil {
+				return fmt.Errorf("rewrite imports of %s: %w", filepath.Base(file), err)
+			}
+		}
+	}
+	sg.Logger(ctx).Printf("inlined %s/targets %s into .sage/targets", sageCiModule, module.Version)
+	return nil
+}
+
+// modulePath returns the module path declared in the go.mod file at path.
+func modulePath(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read go.mod: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("no module directive in %s", path)
+}
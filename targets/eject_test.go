@@ -0,0 +1,77 @@
+package targets
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetSagefileFields(t *testing.T) {
+	settings := []setting{
+		{"skip-workflows", "SkipWorkflows", []string{"sage-ci-stale", "sage-ci-go-ci"}},
+		{"skip-targets-file", "SkipTargetsFile", true},
+	}
+	for _, tt := range []struct {
+		name string
+		cfg  string
+		want string
+	}{
+		{
+			name: "multi-line",
+			cfg: `var cfg = config.Config{
+	// GoModules lists the Go modules.
+	GoModules: []string{"."},
+
+	SkipWorkflows: []string{"sage-ci-stale"},
+}
+`,
+			want: `var cfg = config.Config{
+	// GoModules lists the Go modules.
+	GoModules: []string{"."},
+
+	SkipWorkflows:   []string{"sage-ci-stale", "sage-ci-go-ci"},
+	SkipTargetsFile: true,
+}
+`,
+		},
+		{
+			name: "single-line",
+			cfg:  "var cfg = config.Config{GoModules: []string{\".\"}}\n",
+			want: `var cfg = config.Config{
+	GoModules:       []string{"."},
+	SkipWorkflows:   []string{"sage-ci-stale", "sage-ci-go-ci"},
+	SkipTargetsFile: true,
+}
+`,
+		},
+		{
+			name: "empty",
+			cfg:  "var cfg = config.Config{}\n",
+			want: `var cfg = config.Config{
+	SkipWorkflows:   []string{"sage-ci-stale", "sage-ci-go-ci"},
+	SkipTargetsFile: true,
+}
+`,
+		},
+		{
+			name: "loaded",
+			cfg:  "var cfg = config.MustLoad(sg.FromSageDir(), config.Config{})\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			const header = "package main\n\nimport \"github.com/fredrikaverpil/sage-ci/config\"\n\n"
+			got, err := setSagefileFields([]byte(header+tt.cfg), settings)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("setSagefileFields() = %s, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setSagefileFields() failed: %v", err)
+			}
+			if got := strings.TrimPrefix(string(got), header); got != tt.want {
+				t.Errorf("setSagefileFields() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package targets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"github.com/fredrikaverpil/sage-ci/workflows/github"
+)
+
+// Explanation describes the effective configuration: the values after defaults are applied,
+// which targets run for which modules and which workflows are generated.
+type Explanation struct {
+	Config    []ConfigValue     `json:"config"`
+	Targets   []TargetRun       `json:"targets"`
+	Workflows []github.Workflow `json:"workflows"`
+}
+
+// ConfigValue is an effective configuration value.
+type ConfigValue struct {
+	// Field is the config.Config field name, e.g. "GoVersions".
+	Field string `json:"field"`
+	// Key is the configuration file key, e.g. "go-versions".
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+	// Default reports whether the value was filled in by config.Config.WithDefaults.
+	Default bool `json:"default"`
+}
+
+// TargetRun describes whether a target runs for a module.
+type TargetRun struct {
+	Target string `json:"target"`
+	// Module is the module path, or the file glob for targets operating on files.
+	Module string `json:"module"`
+	Run    bool   `json:"run"`
+	// Reason explains why the target does not run, e.g. the matching SkipTargets rule.
+	// For a file glob the target runs for, it lists the SkipTargets rules skipping some of its files.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Explain returns the Explanation for cfg.
+func Explain(cfg config.Config) (Explanation, error) {
+	effective := cfg.WithDefaults()
+	var e Explanation
+
+	e.Config = configValues(reflect.ValueOf(cfg), reflect.ValueOf(effective), "", "")
+
+	for _, t := range allTargets {
+		modules, files := t.modules(effective)
+		if files {
+			for _, glob := range modules {
+				run := TargetRun{Target: t.Name, Module: glob, Run: true}
+				if rule, ok := effective.SkipTargets.Rule(t.Name, glob); ok {
+					run.Run, run.Reason = false, "SkipTargets "+rule
+				} else if rules := effective.SkipTargets.Rules(t.Name); len(rules) > 0 {
+					// The rules skip single files, which may or may not match the glob.
+					run.Reason = "except files matched by SkipTargets " + strings.Join(rules, ", ")
+				}
+				e.Targets = append(e.Targets, run)
+			}
+			continue
+		}
+		for _, module := range modules {
+			run := TargetRun{Target: t.Name, Module: module, Run: true}
+			if rule, ok := effective.SkipTargets.Rule(t.Name, module); ok {
+				run.Run, run.Reason = false, "SkipTargets "+rule
+			} else if reason := notApplicable(effective, t.Name, module); reason != "" {
+				run.Run, run.Reason = false, reason
+			}
+			e.Targets = append(e.Targets, run)
+		}
+	}
+
+	workflows, err := github.Workflows(effective)
+	if err != nil {
+		return Explanation{}, err
+	}
+	e.Workflows = workflows
+	return e, nil
+}
+
+// configValues returns the non-zero fields of the effective struct value, comparing them with set
+// to tell defaults. Struct fields such as Stale are expanded, e.g. into "Stale.DaysBeforeStale".
+func configValues(set, effective reflect.Value, fieldPrefix, keyPrefix string) []ConfigValue {
+	var values []ConfigValue
+	for i := range effective.NumField() {
+		field := effective.Type().Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		value := effective.Field(i)
+		if value.IsZero() {
+			continue
+		}
+		if value.Kind() == reflect.Pointer {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Struct {
+			values = append(values, configValues(set.Field(i), value, field.Name+".", key+".")...)
+			continue
+		}
+		values = append(values, ConfigValue{
+			Field:   fieldPrefix + field.Name,
+			Key:     keyPrefix + key,
+			Value:   value.Interface(),
+			Default: set.Field(i).IsZero(),
+		})
+	}
+	return values
+}
+
+// modules returns the configured modules of the target, or its file globs if files is true.
+func (t TargetInfo) modules(cfg config.Config) (modules []string, files bool) {
+	switch t.ModulesVar {
+	case "GoModules":
+		return cfg.GoModules, false
+	case "PythonModules":
+		return cfg.PythonModules, false
+	case "LuaModules":
+		return cfg.LuaModules, false
+	case "TerraformModules":
+		return cfg.TerraformModules, false
+	case "MarkdownFiles":
+		return cfg.MarkdownFiles, true
+	case "YAMLFiles":
+		return cfg.YAMLFiles, true
+	case "Dockerfiles":
+		return cfg.Dockerfiles, true
+	case "ActionsWorkflows":
+		return cfg.ActionsWorkflows, true
+	}
+	return nil, false
+}
+
+// notApplicable returns why target never runs for module regardless of SkipTargets, if it doesn't.
+func notApplicable(cfg config.Config, target, module string) string {
+	opts := cfg.PythonOptionsFor(module)
+	switch target {
+	case "PythonMypy", "PythonPyright", "PythonTy":
+		if checker := strings.ToLower(strings.TrimPrefix(target, "Python")); string(opts.Typechecker) != checker {
+			return fmt.Sprintf("typechecker is %s", opts.Typechecker)
+		}
+	case "PythonBuild", "PythonSmoke":
+		if !opts.Package {
+			return "not a package"
+		}
+	case "GoBuild", "GoCrossBuild":
+		// Without GoBinaries, GoBuild compiles all modules.
+		hasBinary := slices.ContainsFunc(cfg.GoBinaries, func(binary string) bool {
+			m, ok := goModuleOf(cfg.GoModules, binary)
+			return ok && m == module
+		})
+		if !hasBinary && (target == "GoCrossBuild" || len(cfg.GoBinaries) > 0) {
+			return "no GoBinaries in module"
+		}
+	}
+	return ""
+}
+
+// WriteTable writes the explanation as human-readable tables.
+func (e Explanation) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONFIG\tVALUE\t")
+	for _, v := range e.Config {
+		value, err := json.Marshal(v.Value)
+		if err != nil {
+			return fmt.Errorf("marshal %s: %w", v.Field, err)
+		}
+		var marker string
+		if v.Default {
+			marker = "(default)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Field, value, marker)
+	}
+	fmt.Fprintln(tw, "\t\t")
+	fmt.Fprintln(tw, "TARGET\tMODULE\tSTATUS")
+	for _, t := range e.Targets {
+		status := "run"
+		switch {
+		case !t.Run:
+			status = "skip (" + t.Reason + ")"
+		case t.Reason != "":
+			status = "run (" + t.Reason + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Target, t.Module, status)
+	}
+	fmt.Fprintln(tw, "\t\t")
+	fmt.Fprintln(tw, "WORKFLOW\tSTATUS\t")
+	for _, workflow := range e.Workflows {
+		status := "generate"
+		if !workflow.Generated {
+			status = "skip (" + workflow.SkipReason + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t\n", workflow.Name, status)
+	}
+	return tw.Flush()
+}
+
+// ExplainConfig prints the Explanation for cfg to stdout, formatted as "table" or "json".
+func ExplainConfig(_ context.Context, cfg config.Config, format string) error {
+	e, err := Explain(cfg)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "", "table":
+		return e.WriteTable(os.Stdout)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(e)
+	default:
+		return fmt.Errorf("unknown format %q, expected table or json", format)
+	}
+}
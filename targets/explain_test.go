@@ -0,0 +1,94 @@
+package targets
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+)
+
+func TestExplain(t *testing.T) {
+	cfg := config.Config{
+		GoModules:     []string{".", "examples/demo"},
+		GoVersions:    []string{"1.24"},
+		PythonModules: []string{"py"},
+		PythonOptions: map[string]config.PythonOptions{"py": {Typechecker: config.TypecheckerTy}},
+		MarkdownFiles: []string{"*.md"},
+		YAMLFiles:     []string{".github/**/*.yml", "docs/**/*.yml"},
+		SkipTargets:   config.SkipTargets{"Go*": {"examples/**"}, "MarkdownFormat": {"*"}, "YamlLint": {"docs/**"}},
+		SkipWorkflows: []string{"sage-ci-stale"},
+		Stale:         config.StaleConfig{DaysBeforeStale: config.Days(60)},
+	}
+	e, err := Explain(cfg)
+	if err != nil {
+		t.Fatalf("Explain() failed: %v", err)
+	}
+
+	defaults := map[string]bool{}
+	for _, v := range e.Config {
+		defaults[v.Field] = v.Default
+	}
+	if defaults["GoVersions"] || !defaults["PythonVersions"] {
+		t.Errorf("Config defaults = %v, want GoVersions set and PythonVersions defaulted", defaults)
+	}
+	if defaults["Stale.DaysBeforeStale"] || !defaults["Stale.DaysBeforeClose"] {
+		t.Errorf("Config defaults = %v, want Stale.DaysBeforeStale set and Stale.DaysBeforeClose defaulted", defaults)
+	}
+
+	runs := map[string]TargetRun{}
+	for _, run := range e.Targets {
+		runs[run.Target+" "+run.Module] = run
+	}
+	for key, want := range map[string]TargetRun{
+		"GoTest .":             {Target: "GoTest", Module: ".", Run: true},
+		"GoTest examples/demo": {Target: "GoTest", Module: "examples/demo", Reason: "SkipTargets Go*: examples/**"},
+		"GoCrossBuild .":       {Target: "GoCrossBuild", Module: ".", Reason: "no GoBinaries in module"},
+		"PythonTy py":          {Target: "PythonTy", Module: "py", Run: true},
+		"PythonMypy py":        {Target: "PythonMypy", Module: "py", Reason: "typechecker is ty"},
+		"MarkdownFormat *.md":  {Target: "MarkdownFormat", Module: "*.md", Reason: "SkipTargets MarkdownFormat: *"},
+		"YamlLint docs/**/*.yml": {
+			Target: "YamlLint", Module: "docs/**/*.yml", Reason: "SkipTargets YamlLint: docs/**",
+		},
+		"YamlLint .github/**/*.yml": {
+			Target: "YamlLint", Module: ".github/**/*.yml", Run: true,
+			Reason: "except files matched by SkipTargets YamlLint: docs/**",
+		},
+		"PythonBuild py": {Target: "PythonBuild", Module: "py", Reason: "not a package"},
+		"GoBuild .":      {Target: "GoBuild", Module: ".", Run: true},
+	} {
+		if got := runs[key]; got != want {
+			t.Errorf("target %s = %+v, want %+v", key, got, want)
+		}
+	}
+	if _, ok := runs["LuaFormat *"]; ok {
+		t.Error("LuaFormat should not be explained without Lua modules")
+	}
+
+	generated := map[string]string{}
+	for _, workflow := range e.Workflows {
+		generated[workflow.Name] = workflow.SkipReason
+	}
+	if reason := generated["sage-ci-stale"]; reason != "matched by SkipWorkflows" {
+		t.Errorf("sage-ci-stale skip reason = %q", reason)
+	}
+	if reason, ok := generated["sage-ci-go-ci"]; !ok || reason != "" {
+		t.Errorf("sage-ci-go-ci should be generated, got reason %q", reason)
+	}
+	if reason := generated["sage-ci-lua-ci"]; reason == "" {
+		t.Error("sage-ci-lua-ci should be skipped")
+	}
+
+	var buf bytes.Buffer
+	if err := e.WriteTable(&buf); err != nil {
+		t.Fatalf("WriteTable() failed: %v", err)
+	}
+	for _, want := range []string{
+		"PythonVersions", "(default)", "skip (SkipTargets Go*: examples/**)",
+		"run (except files matched by SkipTargets YamlLint: docs/**)", "sage-ci-stale",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("WriteTable() output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package targets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/fredrikaverpil/sage-ci/config"
+	"go.einride.tech/sage/sg"
+)
+
+// matchFiles returns the files known to git which match patterns, minus excluded and skipped files.
+// File-based targets treat each matched file as a module, so SkipTargets values may list file paths.
+func matchFiles(ctx context.Context, cfg config.Config, target string, patterns, excludes []string) ([]string, error) {
+	for _, pattern := range append(append([]string{}, patterns...), excludes...) {
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("invalid glob pattern: %s", pattern)
+		}
+	}
+	cmd := sg.Command(ctx, "git", "ls-files", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = sg.FromGitRoot()
+	output := strings.TrimSpace(sg.Output(cmd))
+	if output == "" {
+		return nil, nil
+	}
+	var files []string
+	for _, file := range strings.Split(output, "\n") {
+		if !matchesAny(patterns, file) || matchesAny(excludes, file) {
+			continue
+		}
+		if cfg.SkipTargets.ShouldSkip(target, file) {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// matchesAny returns true if path matches any of the glob patterns.
+func matchesAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if doublestar.MatchUnvalidated(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
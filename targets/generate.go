@@ -15,26 +15,50 @@ import (
 type TargetInfo struct {
 	Name       string // e.g., "GoLint"
 	FuncName   string // e.g., "goLint"
-	Ecosystem  string // e.g., "Go", "Python", "Lua"
-	ModulesVar string // e.g., "GoModules", "PythonModules", "LuaModules"
+	Ecosystem  string // e.g., "Go", "Python", "Lua", "Terraform", "Docs"
+	ModulesVar string // e.g., "GoModules", "PythonModules", "LuaModules", "MarkdownFiles"
+	Mutating   bool   // whether the target rewrites project files, e.g. by formatting them
 }
 
 // allTargets defines all available targets grouped by ecosystem.
 var allTargets = []TargetInfo{
 	// Go targets.
-	{Name: "GoModTidy", FuncName: "goModTidy", Ecosystem: "Go", ModulesVar: "GoModules"},
-	{Name: "GoFormat", FuncName: "goFormat", Ecosystem: "Go", ModulesVar: "GoModules"},
+	{Name: "GoModTidy", FuncName: "goModTidy", Ecosystem: "Go", ModulesVar: "GoModules", Mutating: true},
+	{Name: "GoFormat", FuncName: "goFormat", Ecosystem: "Go", ModulesVar: "GoModules", Mutating: true},
 	{Name: "GoLint", FuncName: "goLint", Ecosystem: "Go", ModulesVar: "GoModules"},
 	{Name: "GoTest", FuncName: "goTest", Ecosystem: "Go", ModulesVar: "GoModules"},
 	{Name: "GoVulncheck", FuncName: "goVulncheck", Ecosystem: "Go", ModulesVar: "GoModules"},
+	{Name: "GoBench", FuncName: "goBench", Ecosystem: "Go", ModulesVar: "GoModules"},
+	{Name: "GoGenerate", FuncName: "goGenerate", Ecosystem: "Go", ModulesVar: "GoModules", Mutating: true},
+	{Name: "GoBuild", FuncName: "goBuild", Ecosystem: "Go", ModulesVar: "GoModules"},
+	{Name: "GoCrossBuild", FuncName: "goCrossBuild", Ecosystem: "Go", ModulesVar: "GoModules"},
 	// Python targets.
-	{Name: "PythonSync", FuncName: "pythonSync", Ecosystem: "Python", ModulesVar: "PythonModules"},
-	{Name: "PythonFormat", FuncName: "pythonFormat", Ecosystem: "Python", ModulesVar: "PythonModules"},
-	{Name: "PythonLint", FuncName: "pythonLint", Ecosystem: "Python", ModulesVar: "PythonModules"},
+	{Name: "PythonSync", FuncName: "pythonSync", Ecosystem: "Python", ModulesVar: "PythonModules", Mutating: true},
+	{Name: "PythonFormat", FuncName: "pythonFormat", Ecosystem: "Python", ModulesVar: "PythonModules", Mutating: true},
+	{Name: "PythonLint", FuncName: "pythonLint", Ecosystem: "Python", ModulesVar: "PythonModules", Mutating: true},
 	{Name: "PythonMypy", FuncName: "pythonMypy", Ecosystem: "Python", ModulesVar: "PythonModules"},
+	{Name: "PythonPyright", FuncName: "pythonPyright", Ecosystem: "Python", ModulesVar: "PythonModules"},
+	{Name: "PythonTy", FuncName: "pythonTy", Ecosystem: "Python", ModulesVar: "PythonModules"},
 	{Name: "PythonTest", FuncName: "pythonTest", Ecosystem: "Python", ModulesVar: "PythonModules"},
+	{Name: "PythonTestMatrix", FuncName: "pythonTestMatrix", Ecosystem: "Python", ModulesVar: "PythonModules"},
+	{Name: "PythonBuild", FuncName: "pythonBuild", Ecosystem: "Python", ModulesVar: "PythonModules"},
+	{Name: "PythonSmoke", FuncName: "pythonSmoke", Ecosystem: "Python", ModulesVar: "PythonModules"},
+	{Name: "PythonLockCheck", FuncName: "pythonLockCheck", Ecosystem: "Python", ModulesVar: "PythonModules"},
 	// Lua targets.
-	{Name: "LuaFormat", FuncName: "luaFormat", Ecosystem: "Lua", ModulesVar: "LuaModules"},
+	{Name: "LuaFormat", FuncName: "luaFormat", Ecosystem: "Lua", ModulesVar: "LuaModules", Mutating: true},
+	// Terraform targets.
+	{
+		Name: "TerraformFormat", FuncName: "terraformFormat", Ecosystem: "Terraform", ModulesVar: "TerraformModules",
+		Mutating: true,
+	},
+	{Name: "TerraformValidate", FuncName: "terraformValidate", Ecosystem: "Terraform", ModulesVar: "TerraformModules"},
+	{Name: "TerraformLint", FuncName: "terraformLint", Ecosystem: "Terraform", ModulesVar: "TerraformModules"},
+	// Docs targets.
+	{Name: "MarkdownFormat", FuncName: "markdownFormat", Ecosystem: "Docs", ModulesVar: "MarkdownFiles", Mutating: true},
+	{Name: "YamlLint", FuncName: "yamlLint", Ecosystem: "Docs", ModulesVar: "YAMLFiles"},
+	// Lint targets.
+	{Name: "DockerLint", FuncName: "dockerLint", Ecosystem: "Lint", ModulesVar: "Dockerfiles"},
+	{Name: "ActionsLint", FuncName: "actionsLint", Ecosystem: "Lint", ModulesVar: "ActionsWorkflows"},
 }
 
 // targetsTemplate is the template for generating targets.gen.go.
@@ -62,7 +86,35 @@ func GenerateWorkflows(ctx context.Context) error {
 
 // GenerateTargetsFile generates a targets.gen.go file in the specified directory.
 // It only includes targets for ecosystems that have modules configured.
+// With SkipTargetsFile, nothing is generated.
 func GenerateTargetsFile(cfg config.Config, outputDir string) error {
+	if cfg.SkipTargetsFile {
+		return nil
+	}
+	content, err := renderTargetsFile(cfg)
+	if err != nil {
+		return err
+	}
+
+	outputPath := filepath.Join(outputDir, "targets.gen.go")
+	if content == nil {
+		// No targets to generate; remove the file if it exists.
+		if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove targets.gen.go: %w", err)
+		}
+		return nil
+	}
+
+	// Write to file.
+	if err := os.WriteFile(outputPath, content, 0o644); err != nil {
+		return fmt.Errorf("write targets.gen.go: %w", err)
+	}
+
+	return nil
+}
+
+// renderTargetsFile returns the content of targets.gen.go for cfg, or nil if no targets are enabled.
+func renderTargetsFile(cfg config.Config) ([]byte, error) {
 	// Filter targets based on configured ecosystems.
 	var enabledTargets []TargetInfo
 	for _, t := range allTargets {
@@ -79,22 +131,37 @@ func GenerateTargetsFile(cfg config.Config, outputDir string) error {
 			if len(cfg.LuaModules) > 0 {
 				enabledTargets = append(enabledTargets, t)
 			}
+		case "TerraformModules":
+			if len(cfg.TerraformModules) > 0 {
+				enabledTargets = append(enabledTargets, t)
+			}
+		case "MarkdownFiles":
+			if len(cfg.MarkdownFiles) > 0 {
+				enabledTargets = append(enabledTargets, t)
+			}
+		case "YAMLFiles":
+			if len(cfg.YAMLFiles) > 0 {
+				enabledTargets = append(enabledTargets, t)
+			}
+		case "Dockerfiles":
+			if len(cfg.Dockerfiles) > 0 {
+				enabledTargets = append(enabledTargets, t)
+			}
+		case "ActionsWorkflows":
+			if len(cfg.ActionsWorkflows) > 0 {
+				enabledTargets = append(enabledTargets, t)
+			}
 		}
 	}
 
 	if len(enabledTargets) == 0 {
-		// No targets to generate; remove the file if it exists.
-		outputPath := filepath.Join(outputDir, "targets.gen.go")
-		if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("remove targets.gen.go: %w", err)
-		}
-		return nil
+		return nil, nil
 	}
 
 	// Parse and execute template.
 	tmpl, err := template.New("targets").Parse(targetsTemplate)
 	if err != nil {
-		return fmt.Errorf("parse template: %w", err)
+		return nil, fmt.Errorf("parse template: %w", err)
 	}
 
 	var buf bytes.Buffer
@@ -104,20 +171,13 @@ func GenerateTargetsFile(cfg config.Config, outputDir string) error {
 		Targets: enabledTargets,
 	}
 	if err := tmpl.Execute(&buf, data); err != nil {
-		return fmt.Errorf("execute template: %w", err)
+		return nil, fmt.Errorf("execute template: %w", err)
 	}
 
 	// Format the generated code.
 	formatted, err := format.Source(buf.Bytes())
 	if err != nil {
-		return fmt.Errorf("format generated code: %w", err)
-	}
-
-	// Write to file.
-	outputPath := filepath.Join(outputDir, "targets.gen.go")
-	if err := os.WriteFile(outputPath, formatted, 0o644); err != nil {
-		return fmt.Errorf("write targets.gen.go: %w", err)
+		return nil, fmt.Errorf("format generated code: %w", err)
 	}
-
-	return nil
+	return formatted, nil
 }
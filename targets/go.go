@@ -2,22 +2,31 @@ package targets
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/fredrikaverpil/sage-ci/config"
 	"github.com/fredrikaverpil/sage-ci/tools/sggolangcilint"
 	"go.einride.tech/sage/sg"
-	"go.einride.tech/sage/tools/sggo"
 )
 
 // GoModTidy runs go mod tidy for all configured Go modules.
 func GoModTidy(ctx context.Context, cfg config.Config) error {
-	for _, module := range cfg.GoModules {
+	for _, module := range selectModules(cfg.GoModules) {
 		if cfg.SkipTargets.ShouldSkip("GoModTidy", module) {
 			continue
 		}
 		sg.Logger(ctx).Printf("running go mod tidy in %s...", module)
 		cmd := sg.Command(ctx, "go", "mod", "tidy", "-v")
-		cmd.Dir = sg.FromGitRoot(module)
+		moduleCommand(cmd, cfg, "GoModTidy", module, 0)
 		if err := cmd.Run(); err != nil {
 			return err
 		}
@@ -27,13 +36,17 @@ func GoModTidy(ctx context.Context, cfg config.Config) error {
 
 // GoLint runs golangci-lint for all configured Go modules.
 func GoLint(ctx context.Context, cfg config.Config) error {
-	for _, module := range cfg.GoModules {
+	for _, module := range selectModules(cfg.GoModules) {
 		if cfg.SkipTargets.ShouldSkip("GoLint", module) {
 			continue
 		}
 		sg.Logger(ctx).Printf("running golangci-lint --fix in %s...", module)
-		cmd := sggolangcilint.Command(ctx, "run", "--fix", "--allow-parallel-runners", "./...")
-		cmd.Dir = sg.FromGitRoot(module)
+		args := append([]string{"run", "--fix", "--allow-parallel-runners"}, goTagsArgs(cfg, module, "--build-tags")...)
+		if lintConfig := cfg.ModuleOptions[module].GoLintConfig; lintConfig != "" {
+			args = append(args, "--config", sg.FromGitRoot(lintConfig))
+		}
+		cmd := sggolangcilint.Command(ctx, append(args, "./...")...)
+		moduleCommand(cmd, cfg, "GoLint", module, 1)
 		if err := cmd.Run(); err != nil {
 			return err
 		}
@@ -43,13 +56,13 @@ func GoLint(ctx context.Context, cfg config.Config) error {
 
 // GoFormat runs gofmt for all configured Go modules.
 func GoFormat(ctx context.Context, cfg config.Config) error {
-	for _, module := range cfg.GoModules {
+	for _, module := range selectModules(cfg.GoModules) {
 		if cfg.SkipTargets.ShouldSkip("GoFormat", module) {
 			continue
 		}
 		sg.Logger(ctx).Printf("applying gofmt in %s...", module)
 		cmd := sg.Command(ctx, "gofmt", "-w", ".")
-		cmd.Dir = sg.FromGitRoot(module)
+		moduleCommand(cmd, cfg, "GoFormat", module, 1)
 		if err := cmd.Run(); err != nil {
 			return err
 		}
@@ -59,13 +72,67 @@ func GoFormat(ctx context.Context, cfg config.Config) error {
 
 // GoTest runs go test for all configured Go modules.
 func GoTest(ctx context.Context, cfg config.Config) error {
-	for _, module := range cfg.GoModules {
+	for _, module := range selectModules(cfg.GoModules) {
 		if cfg.SkipTargets.ShouldSkip("GoTest", module) {
 			continue
 		}
+		opts := cfg.GoTestOptionsFor(module)
 		sg.Logger(ctx).Printf("running go test in %s...", module)
-		cmd := sggo.TestCommand(ctx)
-		cmd.Dir = sg.FromGitRoot(module)
+		cmd := goTestCommand(ctx, opts)
+		moduleCommand(cmd, cfg, "GoTest", module, 1)
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+		if opts.FuzzTime != "" {
+			if err := goFuzzSmoke(ctx, cfg, module, opts); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// goTestCommand returns a go test command with coverage, configured by opts.
+// The zero value of opts matches sggo.TestCommand.
+func goTestCommand(ctx context.Context, opts config.GoTestOptions) *exec.Cmd {
+	coverFile := sg.FromBuildDir("go", "coverage", "go-test.txt")
+	args := []string{"test"}
+	if !opts.NoShuffle {
+		args = append(args, "-shuffle", "on")
+	}
+	if !opts.NoRace {
+		args = append(args, "-race")
+	}
+	if opts.Count > 0 {
+		args = append(args, "-count", strconv.Itoa(opts.Count))
+	}
+	if len(opts.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(opts.Tags, ","))
+	}
+	args = append(args, "-coverprofile", coverFile, "-covermode", "atomic", "./...")
+	return sg.Command(ctx, "go", args...)
+}
+
+// goFuzzSmoke runs each Fuzz* function in module for opts.FuzzTime.
+// Go can only fuzz one function in one package at a time, so they are run one by one.
+func goFuzzSmoke(ctx context.Context, cfg config.Config, module string, opts config.GoTestOptions) error {
+	args := []string{"test", "-list", "^Fuzz"}
+	if len(opts.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(opts.Tags, ","))
+	}
+	listCmd := inModule(sg.Command(ctx, "go", append(args, "./...")...), cfg, module)
+	listCmd.Stdout = nil
+	output, err := listCmd.Output()
+	if err != nil {
+		return fmt.Errorf("list fuzz tests in %s: %w", module, err)
+	}
+	for _, fuzzTest := range parseTestList(string(output)) {
+		sg.Logger(ctx).Printf("fuzzing %s in %s for %s...", fuzzTest.name, fuzzTest.pkg, opts.FuzzTime)
+		fuzzArgs := []string{"test", "-run", "^$", "-fuzz", "^" + fuzzTest.name + "$", "-fuzztime", opts.FuzzTime}
+		if len(opts.Tags) > 0 {
+			fuzzArgs = append(fuzzArgs, "-tags", strings.Join(opts.Tags, ","))
+		}
+		cmd := inModule(sg.Command(ctx, "go", append(fuzzArgs, fuzzTest.pkg)...), cfg, module)
 		if err := cmd.Run(); err != nil {
 			return err
 		}
@@ -73,18 +140,305 @@ func GoTest(ctx context.Context, cfg config.Config) error {
 	return nil
 }
 
+// listedTest is a test function reported by go test -list.
+type listedTest struct {
+	pkg  string
+	name string
+}
+
+// parseTestList parses go test -list output, where each package's
+// function names are followed by an "ok" line naming the package.
+func parseTestList(output string) []listedTest {
+	var tests []listedTest
+	var pending []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "ok" && len(fields) >= 2:
+			for _, name := range pending {
+				tests = append(tests, listedTest{pkg: fields[1], name: name})
+			}
+			pending = nil
+		case fields[0] == "?":
+			pending = nil
+		case len(fields) == 1:
+			pending = append(pending, fields[0])
+		}
+	}
+	return tests
+}
+
+// GoBench runs go benchmarks for all configured Go modules and compares them with benchstat.
+// Results are compared with the module's baseline in GoBenchBaselineDir.
+// If no baseline exists yet, the results are saved as the baseline.
+func GoBench(ctx context.Context, cfg config.Config) error {
+	cfg = cfg.WithDefaults()
+	for _, module := range selectModules(cfg.GoModules) {
+		if cfg.SkipTargets.ShouldSkip("GoBench", module) {
+			continue
+		}
+		opts := cfg.GoTestOptionsFor(module)
+		benchCount := opts.BenchCount
+		if benchCount == 0 {
+			benchCount = 6
+		}
+		args := []string{"test", "-run", "^$", "-bench", ".", "-benchmem", "-count", strconv.Itoa(benchCount)}
+		if len(opts.Tags) > 0 {
+			args = append(args, "-tags", strings.Join(opts.Tags, ","))
+		}
+		sg.Logger(ctx).Printf("running go benchmarks in %s...", module)
+		cmd := sg.Command(ctx, "go", append(args, "./...")...)
+		moduleCommand(cmd, cfg, "GoBench", module, 1)
+		cmd.Stdout = nil
+		output, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("go benchmarks in %s: %w", module, err)
+		}
+		fileName := benchFileName(module)
+		resultFile := sg.FromBuildDir("go", "bench", fileName)
+		if err := os.WriteFile(resultFile, output, 0o644); err != nil {
+			return fmt.Errorf("write benchmark results: %w", err)
+		}
+		baselineFile := sg.FromGitRoot(cfg.GoBenchBaselineDir, fileName)
+		if _, err := os.Stat(baselineFile); os.IsNotExist(err) {
+			sg.Logger(ctx).Printf("no baseline found, saving results as %s", baselineFile)
+			if err := os.MkdirAll(filepath.Dir(baselineFile), 0o755); err != nil {
+				return fmt.Errorf("create benchmark baseline dir: %w", err)
+			}
+			if err := os.WriteFile(baselineFile, output, 0o644); err != nil {
+				return fmt.Errorf("write benchmark baseline: %w", err)
+			}
+			continue
+		}
+		statCmd := sg.Command(
+			ctx, "go", "run", "golang.org/x/perf/cmd/benchstat@latest",
+			"baseline="+baselineFile, "current="+resultFile,
+		)
+		if err := statCmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// benchFileName returns the benchmark results file name for a module.
+func benchFileName(module string) string {
+	name := strings.ReplaceAll(filepath.ToSlash(filepath.Clean(module)), "/", "-")
+	if name == "." {
+		name = "root"
+	}
+	return name + ".txt"
+}
+
 // GoVulncheck runs govulncheck for all configured Go modules.
 func GoVulncheck(ctx context.Context, cfg config.Config) error {
-	for _, module := range cfg.GoModules {
+	for _, module := range selectModules(cfg.GoModules) {
 		if cfg.SkipTargets.ShouldSkip("GoVulncheck", module) {
 			continue
 		}
 		sg.Logger(ctx).Printf("running govulncheck in %s...", module)
-		cmd := sg.Command(ctx, "go", "run", "golang.org/x/vuln/cmd/govulncheck@latest", "./...")
-		cmd.Dir = sg.FromGitRoot(module)
+		args := append([]string{"run", "golang.org/x/vuln/cmd/govulncheck@latest"}, goTagsArgs(cfg, module, "-tags")...)
+		cmd := sg.Command(ctx, "go", append(args, "./...")...)
+		moduleCommand(cmd, cfg, "GoVulncheck", module, 1)
 		if err := cmd.Run(); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// GoGenerate runs go generate for all configured Go modules.
+// Afterwards, generated code which differs from the committed code fails the target in CI.
+func GoGenerate(ctx context.Context, cfg config.Config) error {
+	for _, module := range selectModules(cfg.GoModules) {
+		if cfg.SkipTargets.ShouldSkip("GoGenerate", module) {
+			continue
+		}
+		// Files which are already changed only count as drift if go generate changes them further.
+		before, err := gitChanges(sg.FromGitRoot(module))
+		if err != nil {
+			return err
+		}
+		sg.Logger(ctx).Printf("running go generate in %s...", module)
+		args := append([]string{"generate"}, goTagsArgs(cfg, module, "-tags")...)
+		cmd := sg.Command(ctx, "go", append(args, "./...")...)
+		moduleCommand(cmd, cfg, "GoGenerate", module, 1)
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+		if err := checkDrift(ctx, module, before); err != nil {
+			return fmt.Errorf("go generate in %s: %w", module, err)
+		}
+	}
+	return nil
+}
+
+// GoBuild builds all configured Go modules.
+// If GoBinaries are configured, they are built for the host platform into GoDistDir.
+func GoBuild(ctx context.Context, cfg config.Config) error {
+	cfg = cfg.WithDefaults()
+	if len(cfg.GoBinaries) == 0 {
+		for _, module := range selectModules(cfg.GoModules) {
+			if cfg.SkipTargets.ShouldSkip("GoBuild", module) {
+				continue
+			}
+			sg.Logger(ctx).Printf("running go build in %s...", module)
+			args := append([]string{"build"}, goTagsArgs(cfg, module, "-tags")...)
+			cmd := sg.Command(ctx, "go", append(args, "./...")...)
+			moduleCommand(cmd, cfg, "GoBuild", module, 1)
+			if err := cmd.Run(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, binary := range cfg.GoBinaries {
+		if err := goBuildBinary(ctx, cfg, "GoBuild", binary, runtime.GOOS, runtime.GOARCH, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GoCrossBuild builds all configured GoBinaries for each of the GoPlatforms into GoDistDir.
+func GoCrossBuild(ctx context.Context, cfg config.Config) error {
+	cfg = cfg.WithDefaults()
+	for _, binary := range cfg.GoBinaries {
+		for _, platform := range cfg.GoPlatforms {
+			goos, goarch, ok := strings.Cut(platform, "/")
+			if !ok {
+				return fmt.Errorf("invalid Go platform %q, expected GOOS/GOARCH", platform)
+			}
+			if err := goBuildBinary(ctx, cfg, "GoCrossBuild", binary, goos, goarch, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// goBuildBinary builds the main package at binary with version stamping into GoDistDir.
+// Cross-compiled binaries get a _GOOS_GOARCH suffix.
+func goBuildBinary(ctx context.Context, cfg config.Config, target, binary, goos, goarch string, cross bool) error {
+	module, ok := goModuleOf(cfg.GoModules, binary)
+	if !ok {
+		return fmt.Errorf("go binary %s is not within any of the configured Go modules", binary)
+	}
+	if cfg.SkipTargets.ShouldSkip(target, module) || !slices.Contains(selectModules(cfg.GoModules), module) {
+		return nil
+	}
+	pkg, err := filepath.Rel(module, binary)
+	if err != nil {
+		return fmt.Errorf("resolve package path for %s: %w", binary, err)
+	}
+	output := filepath.Base(filepath.Clean(binary))
+	if output == "." {
+		output = filepath.Base(sg.FromGitRoot(module))
+	}
+	if cross {
+		output = fmt.Sprintf("%s_%s_%s", output, goos, goarch)
+	}
+	if goos == "windows" {
+		output += ".exe"
+	}
+	sg.Logger(ctx).Printf("building %s for %s/%s...", binary, goos, goarch)
+	ldflags := fmt.Sprintf("-s -w -X %s=%s", cfg.GoVersionVar, gitVersion(ctx))
+	args := []string{"build", "-trimpath", "-ldflags", ldflags, "-o", sg.FromGitRoot(cfg.GoDistDir, output)}
+	args = append(args, goTagsArgs(cfg, module, "-tags")...)
+	cmd := sg.Command(ctx, "go", append(args, "./"+filepath.ToSlash(pkg))...)
+	moduleCommand(cmd, cfg, target, module, 1)
+	cmd.Env = append(cmd.Env, "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=0")
+	return cmd.Run()
+}
+
+// goModuleOf returns the innermost configured Go module containing path.
+func goModuleOf(modules []string, path string) (string, bool) {
+	path = filepath.Clean(path)
+	var found string
+	var ok bool
+	for _, module := range modules {
+		module = filepath.Clean(module)
+		rel, err := filepath.Rel(module, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if !ok || len(module) > len(found) {
+			found, ok = module, true
+		}
+	}
+	return found, ok
+}
+
+// gitVersion returns a version string describing the current git commit.
+func gitVersion(ctx context.Context) string {
+	cmd := sg.Command(ctx, "git", "describe", "--tags", "--always", "--dirty")
+	cmd.Stdout = nil
+	output, err := cmd.Output()
+	if err != nil {
+		return "dev"
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// gitChanges returns the modified, deleted and untracked files below dir, relative to dir,
+// mapped to the SHA-256 digest of their content, or "deleted".
+func gitChanges(dir string) (map[string]string, error) {
+	cmd := exec.Command("git", "ls-files", "--modified", "--deleted", "--others", "--exclude-standard", "-z")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files: %w", err)
+	}
+	changes := make(map[string]string)
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file == "" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			changes[file] = "deleted"
+			continue
+		}
+		sum := sha256.Sum256(content)
+		changes[file] = hex.EncodeToString(sum[:])
+	}
+	return changes, nil
+}
+
+// checkDrift reports the files below path which changed since the before snapshot from gitChanges.
+// Like GitDiffCheck, drift only fails in CI.
+func checkDrift(ctx context.Context, path string, before map[string]string) error {
+	after, err := gitChanges(sg.FromGitRoot(path))
+	if err != nil {
+		return err
+	}
+	changed := driftedFiles(before, after)
+	if len(changed) == 0 {
+		return nil
+	}
+	if os.Getenv("CI") == "" {
+		sg.Logger(ctx).Printf("warning: generated files differ from committed files in %s", path)
+		return nil
+	}
+	sg.Logger(ctx).Printf("changed files:\n%s", strings.Join(changed, "\n"))
+	return fmt.Errorf("generated files differ from committed files")
+}
+
+// driftedFiles returns the sorted files whose state differs between the gitChanges snapshots before and after.
+func driftedFiles(before, after map[string]string) []string {
+	var changed []string
+	for file, state := range after {
+		if before[file] != state {
+			changed = append(changed, file)
+		}
+	}
+	for file := range before {
+		if _, ok := after[file]; !ok {
+			changed = append(changed, file)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
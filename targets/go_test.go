@@ -0,0 +1,99 @@
+package targets
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseTestList(t *testing.T) {
+	output := `FuzzParse
+FuzzDecode
+ok  	example.com/foo/parser	0.005s
+?   	example.com/foo/cmd	[no test files]
+FuzzRoundTrip
+ok  	example.com/foo/codec	0.004s
+ok  	example.com/foo/empty	0.003s
+`
+	want := []listedTest{
+		{pkg: "example.com/foo/parser", name: "FuzzParse"},
+		{pkg: "example.com/foo/parser", name: "FuzzDecode"},
+		{pkg: "example.com/foo/codec", name: "FuzzRoundTrip"},
+	}
+	if got := parseTestList(output); !slices.Equal(got, want) {
+		t.Errorf("parseTestList() = %v, want %v", got, want)
+	}
+}
+
+func TestGoModuleOf(t *testing.T) {
+	modules := []string{".", "tools", "tools/sub"}
+	for _, tt := range []struct {
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{path: "cmd/app", want: ".", wantOK: true},
+		{path: "tools/cmd/gen", want: "tools", wantOK: true},
+		{path: "tools/sub/cmd/x", want: "tools/sub", wantOK: true},
+		{path: "toolsx/cmd", want: ".", wantOK: true},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := goModuleOf(modules, tt.path)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("goModuleOf(%q) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+	if _, ok := goModuleOf([]string{"tools"}, "cmd/app"); ok {
+		t.Error("goModuleOf should not find a module for a path outside all modules")
+	}
+}
+
+func TestGitChangesDrift(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+	write := func(file, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write("gen.go", "package a\n")
+	write("notes.md", "notes\n")
+	write("edited.go", "package a\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	// Unrelated and generated files which are already changed before generating.
+	write("notes.md", "unrelated edit\n")
+	write("edited.go", "package a // edited\n")
+	before, err := gitChanges(dir)
+	if err != nil {
+		t.Fatalf("gitChanges() failed: %v", err)
+	}
+	if got := driftedFiles(before, before); len(got) != 0 {
+		t.Errorf("driftedFiles() without changes = %v, want none", got)
+	}
+
+	write("gen.go", "package a // generated\n")
+	write("edited.go", "package a // regenerated\n")
+	write("new_gen.go", "package a\n")
+	after, err := gitChanges(dir)
+	if err != nil {
+		t.Fatalf("gitChanges() failed: %v", err)
+	}
+	want := []string{"edited.go", "gen.go", "new_gen.go"}
+	if got := driftedFiles(before, after); !slices.Equal(got, want) {
+		t.Errorf("driftedFiles() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package targets
+
+import (
+	"context"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"github.com/fredrikaverpil/sage-ci/tools/sgactionlint"
+	"github.com/fredrikaverpil/sage-ci/tools/sghadolint"
+	"go.einride.tech/sage/sg"
+)
+
+// DockerLint runs hadolint for all configured Dockerfiles.
+func DockerLint(ctx context.Context, cfg config.Config) error {
+	files, err := matchFiles(ctx, cfg, "DockerLint", cfg.Dockerfiles, nil)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	sg.Logger(ctx).Printf("running hadolint on %d file(s)...", len(files))
+	cmd := sghadolint.Command(ctx, files...)
+	cmd.Dir = sg.FromGitRoot()
+	return cmd.Run()
+}
+
+// ActionsLint runs actionlint for all configured GitHub Actions workflows.
+func ActionsLint(ctx context.Context, cfg config.Config) error {
+	files, err := matchFiles(ctx, cfg, "ActionsLint", cfg.ActionsWorkflows, nil)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	sg.Logger(ctx).Printf("running actionlint on %d file(s)...", len(files))
+	cmd := sgactionlint.Command(ctx, files...)
+	cmd.Dir = sg.FromGitRoot()
+	return cmd.Run()
+}
@@ -0,0 +1,240 @@
+package targets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"github.com/fredrikaverpil/sage-ci/tools/sgactionlint"
+	"github.com/fredrikaverpil/sage-ci/tools/sggolangcilint"
+	"github.com/fredrikaverpil/sage-ci/tools/sghadolint"
+	"github.com/fredrikaverpil/sage-ci/tools/sgmdformat"
+	"github.com/fredrikaverpil/sage-ci/tools/sgopentofu"
+	"github.com/fredrikaverpil/sage-ci/tools/sgstylua"
+	"github.com/fredrikaverpil/sage-ci/tools/sgterraform"
+	"github.com/fredrikaverpil/sage-ci/tools/sgtflint"
+	"github.com/fredrikaverpil/sage-ci/tools/sgtreesittercli"
+	"github.com/fredrikaverpil/sage-ci/tools/sgtsqueryls"
+	"github.com/fredrikaverpil/sage-ci/tools/sgyamllint"
+	"github.com/fredrikaverpil/sage-ci/workflows/github"
+	"go.einride.tech/sage/sg"
+)
+
+// Listing describes what sage-ci provides: its targets, workflow templates and tools,
+// and how they apply to a configuration.
+type Listing struct {
+	Targets   []ListedTarget    `json:"targets"`
+	Workflows []github.Workflow `json:"workflows"`
+	Tools     []ListedTool      `json:"tools"`
+}
+
+// ListedTarget describes a target sage-ci can generate.
+type ListedTarget struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`
+	// Mutating reports whether the target rewrites project files, e.g. by formatting them.
+	Mutating bool `json:"mutating"`
+	// Enabled reports whether the target runs for at least one module or file glob.
+	Enabled bool `json:"enabled"`
+	// Modules are the modules or file globs the target runs for.
+	Modules []string `json:"modules,omitempty"`
+}
+
+// ListedTool describes a tool sage-ci runs.
+type ListedTool struct {
+	Name string `json:"name"`
+	// Version is the pinned version. uv is pinned by Sage, so its version is the installed one, if any.
+	Version string `json:"version"`
+	// Installed reports whether the tool is in the Sage tools cache. Python tools are fetched by uv
+	// on first use, so they count as installed when uv is.
+	Installed bool `json:"installed"`
+	// Targets are the targets running the tool, with "Python*" standing for all Python targets.
+	Targets []string `json:"targets,omitempty"`
+}
+
+// toolInfo describes a tool in the Sage tools cache, or a Python tool run through uv if uv is set.
+type toolInfo struct {
+	name, version string
+	uv            bool
+	targets       []string
+}
+
+// allTools defines the tools sage-ci runs, besides uv which is provided by Sage.
+var allTools = []toolInfo{
+	{name: sgactionlint.Name, version: sgactionlint.Version, targets: []string{"ActionsLint"}},
+	{name: sggolangcilint.Name, version: sggolangcilint.Version, targets: []string{"GoLint"}},
+	{name: sghadolint.Name, version: sghadolint.Version, targets: []string{"DockerLint"}},
+	{name: sgmdformat.Name, version: sgmdformat.Version, uv: true, targets: []string{"MarkdownFormat"}},
+	{name: sgstylua.Name, version: sgstylua.Version, targets: []string{"LuaFormat"}},
+	{
+		name: sgterraform.Name, version: sgterraform.Version,
+		targets: []string{"TerraformFormat", "TerraformValidate"},
+	},
+	{name: sgtflint.Name, version: sgtflint.Version, targets: []string{"TerraformLint"}},
+	{
+		name: sgopentofu.Name, version: sgopentofu.Version,
+		targets: []string{"TerraformFormat", "TerraformValidate"},
+	},
+	{name: sgtreesittercli.Name, version: sgtreesittercli.Version},
+	{name: sgtsqueryls.Name, version: sgtsqueryls.Version},
+	{name: sgyamllint.Name, version: sgyamllint.Version, uv: true, targets: []string{"YamlLint"}},
+}
+
+// List returns the Listing for cfg. Installed tools are looked up in toolsDir, the Sage tools cache.
+func List(cfg config.Config, toolsDir string) (Listing, error) {
+	e, err := Explain(cfg)
+	if err != nil {
+		return Listing{}, err
+	}
+	l := Listing{Workflows: e.Workflows}
+
+	for _, t := range allTargets {
+		target := ListedTarget{Name: t.Name, Ecosystem: t.Ecosystem, Mutating: t.Mutating}
+		for _, run := range e.Targets {
+			if run.Target == t.Name && run.Run {
+				target.Enabled = true
+				target.Modules = append(target.Modules, run.Module)
+			}
+		}
+		l.Targets = append(l.Targets, target)
+	}
+
+	uvVersion, uvInstalled := installedUv(toolsDir)
+	l.Tools = append(l.Tools, ListedTool{
+		Name: "uv", Version: uvVersion, Installed: uvInstalled,
+		Targets: []string{"Python*", "MarkdownFormat", "YamlLint"},
+	})
+	for _, t := range allTools {
+		tool := ListedTool{Name: t.name, Version: t.version, Installed: uvInstalled, Targets: t.targets}
+		if !t.uv {
+			_, err := os.Stat(filepath.Join(toolsDir, t.name, t.version))
+			tool.Installed = err == nil
+		}
+		l.Tools = append(l.Tools, tool)
+	}
+	return l, nil
+}
+
+// installedUv returns the version of uv in toolsDir, and whether it is installed.
+func installedUv(toolsDir string) (string, bool) {
+	dirs, err := filepath.Glob(filepath.Join(toolsDir, "uv", "*", "bin"))
+	if err != nil {
+		return "", false
+	}
+	// Sage only uses the version it pins, which is the latest installed one unless the cache is stale.
+	var latest string
+	var latestVersion config.Version
+	for _, dir := range dirs {
+		name := filepath.Base(filepath.Dir(dir))
+		v, err := config.ParseVersion(name)
+		if err != nil {
+			continue
+		}
+		if latest == "" || v.Compare(latestVersion) > 0 {
+			latest, latestVersion = name, v
+		}
+	}
+	return latest, latest != ""
+}
+
+// WriteTable writes the view of the listing, "targets", "workflows", "tools" or "all", as tables to w.
+func (l Listing) WriteTable(w io.Writer, view string) error {
+	// Each table gets its own tabwriter, so the columns of one don't widen the others.
+	var tables []func(tw *tabwriter.Writer)
+	if view == "all" || view == "targets" {
+		tables = append(tables, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "TARGET\tECOSYSTEM\tKIND\tMODULES")
+			for _, t := range l.Targets {
+				kind := "read-only"
+				if t.Mutating {
+					kind = "mutating"
+				}
+				modules := "disabled"
+				if t.Enabled {
+					modules = strings.Join(t.Modules, ", ")
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.Name, t.Ecosystem, kind, modules)
+			}
+		})
+	}
+	if view == "all" || view == "workflows" {
+		tables = append(tables, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "WORKFLOW\tFILE\tSTATUS")
+			for _, workflow := range l.Workflows {
+				status := "generate"
+				if !workflow.Generated {
+					status = "skip (" + workflow.SkipReason + ")"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", workflow.Name, workflow.File, status)
+			}
+		})
+	}
+	if view == "all" || view == "tools" {
+		tables = append(tables, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "TOOL\tVERSION\tSTATUS\tTARGETS")
+			for _, t := range l.Tools {
+				status := "not installed"
+				if t.Installed {
+					status = "installed"
+				}
+				version := t.Version
+				if version == "" {
+					version = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.Name, version, status, strings.Join(t.Targets, ", "))
+			}
+		})
+	}
+	for i, table := range tables {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		table(tw)
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListSageCi prints the Listing for cfg to stdout. view is "targets", "workflows", "tools" or "all",
+// and format is "table" or "json". The JSON of a single view is the list of its items.
+func ListSageCi(_ context.Context, cfg config.Config, view, format string) error {
+	if view == "" {
+		view = "all"
+	}
+	l, err := List(cfg, sg.FromSageDir("tools"))
+	if err != nil {
+		return err
+	}
+	var value any
+	switch view {
+	case "all":
+		value = l
+	case "targets":
+		value = l.Targets
+	case "workflows":
+		value = l.Workflows
+	case "tools":
+		value = l.Tools
+	default:
+		return fmt.Errorf("unknown view %q, expected targets, workflows, tools or all", view)
+	}
+	switch format {
+	case "", "table":
+		return l.WriteTable(os.Stdout, view)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	default:
+		return fmt.Errorf("unknown format %q, expected table or json", format)
+	}
+}
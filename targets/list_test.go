@@ -0,0 +1,122 @@
+package targets
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"github.com/fredrikaverpil/sage-ci/tools/sggolangcilint"
+)
+
+func TestList(t *testing.T) {
+	cfg := config.Config{
+		GoModules:     []string{".", "examples/demo"},
+		MarkdownFiles: []string{"*.md"},
+		SkipTargets:   config.SkipTargets{"GoLint": {"examples/**"}, "MarkdownFormat": {"*"}},
+		SkipWorkflows: []string{"sage-ci-stale"},
+	}
+	toolsDir := t.TempDir()
+	for _, dir := range []string{
+		filepath.Join("uv", "0.6.12", "bin"),
+		filepath.Join(sggolangcilint.Name, sggolangcilint.Version, "bin"),
+	} {
+		if err := os.MkdirAll(filepath.Join(toolsDir, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l, err := List(cfg, toolsDir)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+
+	listed := map[string]ListedTarget{}
+	for _, target := range l.Targets {
+		listed[target.Name] = target
+	}
+	if len(listed) != len(allTargets) {
+		t.Errorf("List() has %d targets, want all %d", len(listed), len(allTargets))
+	}
+	if got := listed["GoLint"]; !got.Enabled || got.Mutating || strings.Join(got.Modules, ",") != "." {
+		t.Errorf("GoLint = %+v, want enabled read-only target for .", got)
+	}
+	if got := listed["GoFormat"]; !got.Mutating || len(got.Modules) != 2 {
+		t.Errorf("GoFormat = %+v, want mutating target for both modules", got)
+	}
+	for _, name := range []string{"MarkdownFormat", "PythonTest"} {
+		if got := listed[name]; got.Enabled {
+			t.Errorf("%s = %+v, want disabled", name, got)
+		}
+	}
+
+	for _, workflow := range l.Workflows {
+		if workflow.Name == "sage-ci-stale" &&
+			(workflow.Generated || workflow.File != ".github/workflows/sage-ci-stale.yml") {
+			t.Errorf("sage-ci-stale = %+v, want skipped .github/workflows/sage-ci-stale.yml", workflow)
+		}
+	}
+
+	tools := map[string]ListedTool{}
+	for _, tool := range l.Tools {
+		tools[tool.Name] = tool
+	}
+	want := map[string]bool{"uv": true, "golangci-lint": true, "yamllint": true, "stylua": false, "tflint": false}
+	for name, installed := range want {
+		if tools[name].Installed != installed {
+			t.Errorf("tool %s = %+v, want installed %v", name, tools[name], installed)
+		}
+	}
+	if got := tools["uv"].Version; got != "0.6.12" {
+		t.Errorf("uv version = %q, want the installed 0.6.12", got)
+	}
+	if got := tools["golangci-lint"].Version; got != sggolangcilint.Version {
+		t.Errorf("golangci-lint version = %q, want %q", got, sggolangcilint.Version)
+	}
+}
+
+func TestListingWriteTable(t *testing.T) {
+	l := Listing{
+		Targets: []ListedTarget{
+			{Name: "GoFormat", Ecosystem: "Go", Mutating: true, Enabled: true, Modules: []string{".", "tools"}},
+			{Name: "PythonTest", Ecosystem: "Python"},
+		},
+		Tools: []ListedTool{{Name: "stylua", Version: "2.0.1", Targets: []string{"LuaFormat"}}},
+	}
+	var buf bytes.Buffer
+	if err := l.WriteTable(&buf, "targets"); err != nil {
+		t.Fatal(err)
+	}
+	want := "TARGET      ECOSYSTEM  KIND       MODULES\n" +
+		"GoFormat    Go         mutating   ., tools\n" +
+		"PythonTest  Python     read-only  disabled\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteTable(targets) =\n%s\nwant\n%s", got, want)
+	}
+	buf.Reset()
+	if err := l.WriteTable(&buf, "tools"); err != nil {
+		t.Fatal(err)
+	}
+	want = "TOOL    VERSION  STATUS         TARGETS\n" +
+		"stylua  2.0.1    not installed  LuaFormat\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteTable(tools) =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestInstalledUv(t *testing.T) {
+	toolsDir := t.TempDir()
+	if _, ok := installedUv(toolsDir); ok {
+		t.Error("installedUv() without uv = installed, want not installed")
+	}
+	// 0.10.0 sorts before 0.9.2 as a string.
+	for _, version := range []string{"0.9.2", "0.10.0", "0.6.12"} {
+		if err := os.MkdirAll(filepath.Join(toolsDir, "uv", version, "bin"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, ok := installedUv(toolsDir); !ok || got != "0.10.0" {
+		t.Errorf("installedUv() = %q, %t, want 0.10.0, true", got, ok)
+	}
+}
@@ -10,13 +10,13 @@ import (
 
 // LuaFormat runs stylua for all configured Lua modules.
 func LuaFormat(ctx context.Context, cfg config.Config) error {
-	for _, module := range cfg.LuaModules {
+	for _, module := range selectModules(cfg.LuaModules) {
 		if cfg.SkipTargets.ShouldSkip("LuaFormat", module) {
 			continue
 		}
 		sg.Logger(ctx).Printf("applying stylua format in %s...", module)
 		cmd := sgstylua.Command(ctx, ".")
-		cmd.Dir = sg.FromGitRoot(module)
+		moduleCommand(cmd, cfg, "LuaFormat", module, 1)
 		if err := cmd.Run(); err != nil {
 			return err
 		}
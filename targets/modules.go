@@ -0,0 +1,59 @@
+package targets
+
+import (
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"go.einride.tech/sage/sg"
+)
+
+// ModuleEnvVar restricts module targets to a single module when set,
+// e.g. in a CI matrix job for a module with its own versions or OS matrix.
+const ModuleEnvVar = "SAGE_CI_MODULE"
+
+// selectModules returns modules, or only the module named by ModuleEnvVar if set.
+func selectModules(modules []string) []string {
+	module := os.Getenv(ModuleEnvVar)
+	if module == "" {
+		return modules
+	}
+	if slices.Contains(modules, module) {
+		return []string{module}
+	}
+	return nil
+}
+
+// moduleCommand prepares cmd to run target in module, see inModule.
+// The module's ModuleOptions.Args for target are inserted before the last paths arguments,
+// which are the paths the command operates on.
+func moduleCommand(cmd *exec.Cmd, cfg config.Config, target, module string, paths int) *exec.Cmd {
+	inModule(cmd, cfg, module)
+	if args := cfg.ModuleOptions[module].Args[target]; len(args) > 0 {
+		at := len(cmd.Args) - paths
+		cmd.Args = slices.Concat(cmd.Args[:at], args, cmd.Args[at:])
+	}
+	return cmd
+}
+
+// inModule prepares cmd to run in the module directory with the module's ModuleOptions.Env set.
+func inModule(cmd *exec.Cmd, cfg config.Config, module string) *exec.Cmd {
+	env := cfg.ModuleOptions[module].Env
+	cmd.Dir = sg.FromGitRoot(module)
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		cmd.Env = append(cmd.Env, key+"="+env[key])
+	}
+	return cmd
+}
+
+// goTagsArgs returns the build tags flag for module, if it has any ModuleOptions.Tags.
+func goTagsArgs(cfg config.Config, module, flag string) []string {
+	tags := cfg.ModuleOptions[module].Tags
+	if len(tags) == 0 {
+		return nil
+	}
+	return []string{flag, strings.Join(tags, ",")}
+}
@@ -0,0 +1,64 @@
+package targets
+
+import (
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+)
+
+func TestModuleCommand(t *testing.T) {
+	cfg := config.Config{
+		ModuleOptions: map[string]config.ModuleOptions{
+			"tools": {
+				Args: map[string][]string{"GoTest": {"-short"}, "PythonTest": {"-x"}},
+				Env:  map[string]string{"B": "2", "A": "1"},
+			},
+		},
+	}
+	for _, tt := range []struct {
+		name   string
+		target string
+		module string
+		args   []string
+		paths  int
+		want   []string
+	}{
+		{name: "before paths", target: "GoTest", module: "tools", args: []string{"test", "./..."}, paths: 1,
+			want: []string{"go", "test", "-short", "./..."}},
+		{name: "appended", target: "PythonTest", module: "tools", args: []string{"test"}, paths: 0,
+			want: []string{"go", "test", "-x"}},
+		{name: "other target", target: "GoLint", module: "tools", args: []string{"test", "./..."}, paths: 1,
+			want: []string{"go", "test", "./..."}},
+		{name: "other module", target: "GoTest", module: ".", args: []string{"test", "./..."}, paths: 1,
+			want: []string{"go", "test", "./..."}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := moduleCommand(exec.Command("go", tt.args...), cfg, tt.target, tt.module, tt.paths)
+			if !slices.Equal(cmd.Args, tt.want) {
+				t.Errorf("Args = %q, want %q", cmd.Args, tt.want)
+			}
+		})
+	}
+
+	cmd := moduleCommand(exec.Command("go"), cfg, "GoTest", "tools", 0)
+	if !slices.Equal(cmd.Env[len(cmd.Env)-2:], []string{"A=1", "B=2"}) {
+		t.Errorf("Env = %q, want A=1 and B=2 appended in order", cmd.Env)
+	}
+}
+
+func TestSelectModules(t *testing.T) {
+	modules := []string{".", "tools"}
+	if got := selectModules(modules); !slices.Equal(got, modules) {
+		t.Errorf("selectModules() = %q, want %q", got, modules)
+	}
+	t.Setenv(ModuleEnvVar, "tools")
+	if got := selectModules(modules); !slices.Equal(got, []string{"tools"}) {
+		t.Errorf("selectModules() = %q, want [tools]", got)
+	}
+	t.Setenv(ModuleEnvVar, "other")
+	if got := selectModules(modules); len(got) != 0 {
+		t.Errorf("selectModules() = %q, want none", got)
+	}
+}
@@ -2,6 +2,13 @@ package targets
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/fredrikaverpil/sage-ci/config"
 	"go.einride.tech/sage/sg"
@@ -10,13 +17,13 @@ import (
 
 // PythonSync runs uv sync for all configured Python modules.
 func PythonSync(ctx context.Context, cfg config.Config) error {
-	for _, module := range cfg.PythonModules {
+	for _, module := range selectModules(cfg.PythonModules) {
 		if cfg.SkipTargets.ShouldSkip("PythonSync", module) {
 			continue
 		}
 		sg.Logger(ctx).Printf("running uv sync in %s...", module)
 		cmd := sguv.Command(ctx, "sync", "--all-groups")
-		cmd.Dir = sg.FromGitRoot(module)
+		moduleCommand(cmd, cfg, "PythonSync", module, 0)
 		if err := cmd.Run(); err != nil {
 			return err
 		}
@@ -27,13 +34,13 @@ func PythonSync(ctx context.Context, cfg config.Config) error {
 // PythonFormat runs ruff format for all configured Python modules.
 func PythonFormat(ctx context.Context, cfg config.Config) error {
 	sg.Deps(ctx, func(ctx context.Context) error { return PythonSync(ctx, cfg) })
-	for _, module := range cfg.PythonModules {
+	for _, module := range selectModules(cfg.PythonModules) {
 		if cfg.SkipTargets.ShouldSkip("PythonFormat", module) {
 			continue
 		}
 		sg.Logger(ctx).Printf("applying ruff format in %s...", module)
 		cmd := sguv.Command(ctx, "run", "ruff", "format", ".")
-		cmd.Dir = sg.FromGitRoot(module)
+		moduleCommand(cmd, cfg, "PythonFormat", module, 1)
 		if err := cmd.Run(); err != nil {
 			return err
 		}
@@ -44,13 +51,13 @@ func PythonFormat(ctx context.Context, cfg config.Config) error {
 // PythonLint runs ruff check for all configured Python modules.
 func PythonLint(ctx context.Context, cfg config.Config) error {
 	sg.Deps(ctx, func(ctx context.Context) error { return PythonSync(ctx, cfg) })
-	for _, module := range cfg.PythonModules {
+	for _, module := range selectModules(cfg.PythonModules) {
 		if cfg.SkipTargets.ShouldSkip("PythonLint", module) {
 			continue
 		}
 		sg.Logger(ctx).Printf("running ruff check --fix in %s...", module)
 		cmd := sguv.Command(ctx, "run", "ruff", "check", "--fix", ".")
-		cmd.Dir = sg.FromGitRoot(module)
+		moduleCommand(cmd, cfg, "PythonLint", module, 1)
 		if err := cmd.Run(); err != nil {
 			return err
 		}
@@ -58,16 +65,40 @@ func PythonLint(ctx context.Context, cfg config.Config) error {
 	return nil
 }
 
-// PythonMypy runs mypy for all configured Python modules.
+// PythonMypy runs mypy for all configured Python modules using the mypy typechecker.
 func PythonMypy(ctx context.Context, cfg config.Config) error {
+	return pythonTypecheck(ctx, cfg, "PythonMypy", config.TypecheckerMypy, "mypy", ".")
+}
+
+// PythonPyright runs pyright for all configured Python modules using the pyright typechecker.
+func PythonPyright(ctx context.Context, cfg config.Config) error {
+	return pythonTypecheck(ctx, cfg, "PythonPyright", config.TypecheckerPyright, "pyright", ".")
+}
+
+// PythonTy runs ty for all configured Python modules using the ty typechecker.
+func PythonTy(ctx context.Context, cfg config.Config) error {
+	return pythonTypecheck(ctx, cfg, "PythonTy", config.TypecheckerTy, "ty", "check", ".")
+}
+
+// pythonTypecheck runs the typechecker command for modules configured with checker.
+func pythonTypecheck(
+	ctx context.Context,
+	cfg config.Config,
+	target string,
+	checker config.PythonTypechecker,
+	args ...string,
+) error {
 	sg.Deps(ctx, func(ctx context.Context) error { return PythonSync(ctx, cfg) })
-	for _, module := range cfg.PythonModules {
-		if cfg.SkipTargets.ShouldSkip("PythonMypy", module) {
+	for _, module := range selectModules(cfg.PythonModules) {
+		if cfg.PythonOptionsFor(module).Typechecker != checker {
+			continue
+		}
+		if cfg.SkipTargets.ShouldSkip(target, module) {
 			continue
 		}
-		sg.Logger(ctx).Printf("running mypy in %s...", module)
-		cmd := sguv.Command(ctx, "run", "mypy", ".")
-		cmd.Dir = sg.FromGitRoot(module)
+		sg.Logger(ctx).Printf("running %s in %s...", checker, module)
+		cmd := sguv.Command(ctx, append([]string{"run"}, args...)...)
+		moduleCommand(cmd, cfg, target, module, 1)
 		if err := cmd.Run(); err != nil {
 			return err
 		}
@@ -78,16 +109,160 @@ func PythonMypy(ctx context.Context, cfg config.Config) error {
 // PythonTest runs pytest for all configured Python modules.
 func PythonTest(ctx context.Context, cfg config.Config) error {
 	sg.Deps(ctx, func(ctx context.Context) error { return PythonSync(ctx, cfg) })
-	for _, module := range cfg.PythonModules {
+	for _, module := range selectModules(cfg.PythonModules) {
 		if cfg.SkipTargets.ShouldSkip("PythonTest", module) {
 			continue
 		}
 		sg.Logger(ctx).Printf("running pytest in %s...", module)
 		cmd := sguv.Command(ctx, "run", "pytest", "-v")
-		cmd.Dir = sg.FromGitRoot(module)
+		moduleCommand(cmd, cfg, "PythonTest", module, 0)
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PythonTestMatrix runs pytest for all configured Python modules once per PythonVersions entry,
+// or per ModuleOptions.PythonVersions entry for modules which override the versions.
+// Each version runs in an isolated environment, so local runs match the CI matrix.
+// All versions are run before the results are reported per version.
+func PythonTestMatrix(ctx context.Context, cfg config.Config) error {
+	cfg = cfg.WithDefaults()
+	modules := selectModules(cfg.PythonModules)
+	var versions []string
+	for _, module := range modules {
+		for _, version := range cfg.ModuleOptionsFor(module).PythonVersions {
+			if !slices.Contains(versions, version) {
+				versions = append(versions, version)
+			}
+		}
+	}
+	var failedVersions []string
+	for _, version := range versions {
+		var failedModules []string
+		for _, module := range modules {
+			if cfg.SkipTargets.ShouldSkip("PythonTestMatrix", module) ||
+				!slices.Contains(cfg.ModuleOptionsFor(module).PythonVersions, version) {
+				continue
+			}
+			sg.Logger(ctx).Printf("running pytest with Python %s in %s...", version, module)
+			cmd := sguv.Command(ctx, "run", "--isolated", "--all-groups", "--python", version, "pytest", "-v")
+			moduleCommand(cmd, cfg, "PythonTestMatrix", module, 0)
+			if err := cmd.Run(); err != nil {
+				failedModules = append(failedModules, module)
+			}
+		}
+		if len(failedModules) > 0 {
+			sg.Logger(ctx).Printf("Python %s: FAIL (%s)", version, strings.Join(failedModules, ", "))
+			failedVersions = append(failedVersions, version)
+		} else {
+			sg.Logger(ctx).Printf("Python %s: ok", version)
+		}
+	}
+	if len(failedVersions) > 0 {
+		return fmt.Errorf("pytest failed for Python %s", strings.Join(failedVersions, ", "))
+	}
+	return nil
+}
+
+// PythonBuild runs uv build for all configured Python packages.
+func PythonBuild(ctx context.Context, cfg config.Config) error {
+	for _, module := range selectModules(cfg.PythonModules) {
+		opts := cfg.PythonOptionsFor(module)
+		if !opts.Package || cfg.SkipTargets.ShouldSkip("PythonBuild", module) {
+			continue
+		}
+		sg.Logger(ctx).Printf("running uv build in %s...", module)
+		cmd := sguv.Command(ctx, "build", "--out-dir", opts.DistDir)
+		moduleCommand(cmd, cfg, "PythonBuild", module, 0)
 		if err := cmd.Run(); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// PythonSmoke installs the built wheel of all configured Python packages into a
+// throwaway environment and imports the top-level package.
+func PythonSmoke(ctx context.Context, cfg config.Config) error {
+	sg.Deps(ctx, func(ctx context.Context) error { return PythonBuild(ctx, cfg) })
+	for _, module := range selectModules(cfg.PythonModules) {
+		opts := cfg.PythonOptionsFor(module)
+		if !opts.Package || cfg.SkipTargets.ShouldSkip("PythonSmoke", module) {
+			continue
+		}
+		wheel, err := latestWheel(sg.FromGitRoot(module, opts.DistDir))
+		if err != nil {
+			return err
+		}
+		importName := opts.ImportName
+		if importName == "" {
+			// Wheel file names start with the normalized distribution name.
+			importName, _, _ = strings.Cut(filepath.Base(wheel), "-")
+		}
+		sg.Logger(ctx).Printf("importing %s from %s...", importName, filepath.Base(wheel))
+		cmd := sguv.Command(
+			ctx, "run", "--isolated", "--no-project", "--with", wheel,
+			"python", "-c", "import "+importName,
+		)
+		inModule(cmd, cfg, module)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("smoke test %s: %w", module, err)
+		}
+	}
+	return nil
+}
+
+// latestWheel returns the most recently built wheel in dir.
+func latestWheel(dir string) (string, error) {
+	wheels, err := filepath.Glob(filepath.Join(dir, "*.whl"))
+	if err != nil {
+		return "", err
+	}
+	var latest string
+	var latestTime time.Time
+	for _, wheel := range wheels {
+		info, err := os.Stat(wheel)
+		if err != nil {
+			return "", err
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest, latestTime = wheel, info.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no wheel found in %s", dir)
+	}
+	return latest, nil
+}
+
+// PythonLockCheck fails if uv.lock is out of date with pyproject.toml for any configured Python module.
+// Modules without a committed uv.lock are skipped.
+func PythonLockCheck(ctx context.Context, cfg config.Config) error {
+	for _, module := range selectModules(cfg.PythonModules) {
+		if cfg.SkipTargets.ShouldSkip("PythonLockCheck", module) {
+			continue
+		}
+		if !uvLockCommitted(sg.FromGitRoot(module)) {
+			sg.Logger(ctx).Printf("no committed uv.lock in %s, skipping lock check", module)
+			continue
+		}
+		sg.Logger(ctx).Printf("checking uv.lock in %s...", module)
+		// --locked asserts that the lockfile would not change, like uv lock --check.
+		cmd := sguv.Command(ctx, "lock", "--locked")
+		moduleCommand(cmd, cfg, "PythonLockCheck", module, 0)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("uv.lock is out of date in %s: %w", module, err)
+		}
+	}
+	return nil
+}
+
+// uvLockCommitted reports whether the uv.lock in dir is tracked by git.
+// PythonSync creates uv.lock, so an untracked one doesn't mean the project locks its dependencies.
+func uvLockCommitted(dir string) bool {
+	cmd := exec.Command("git", "ls-files", "--error-unmatch", "uv.lock")
+	cmd.Dir = dir
+	return cmd.Run() == nil
+}
@@ -0,0 +1,35 @@
+package targets
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestUvLockCommitted(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+	git("init", "-q")
+	if uvLockCommitted(dir) {
+		t.Error("uvLockCommitted() without uv.lock = true, want false")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "uv.lock"), []byte("version = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if uvLockCommitted(dir) {
+		t.Error("uvLockCommitted() with an untracked uv.lock = true, want false")
+	}
+	git("add", "uv.lock")
+	git("commit", "-q", "-m", "lock")
+	if !uvLockCommitted(dir) {
+		t.Error("uvLockCommitted() with a committed uv.lock = false, want true")
+	}
+}
@@ -0,0 +1,23 @@
+package targets
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+)
+
+func TestSchemaInSync(t *testing.T) {
+	want, err := config.Schema(TargetNames())
+	if err != nil {
+		t.Fatalf("Schema() failed: %v", err)
+	}
+	got, err := os.ReadFile("../sage-ci.schema.json")
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("sage-ci.schema.json is out of date, run: go run ./cmd/sage-ci schema > sage-ci.schema.json")
+	}
+}
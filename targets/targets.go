@@ -5,6 +5,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/fredrikaverpil/sage-ci/config"
 	"github.com/fredrikaverpil/sage-ci/workflows/github"
@@ -58,6 +62,16 @@ func RunSerial(ctx context.Context, cfg config.Config) error {
 			namedTarget{"LuaFormat", func(ctx context.Context) error { return LuaFormat(ctx, cfg) }},
 		)
 	}
+	if len(cfg.TerraformModules) > 0 {
+		deps = append(deps,
+			namedTarget{"TerraformFormat", func(ctx context.Context) error { return TerraformFormat(ctx, cfg) }},
+		)
+	}
+	if len(cfg.MarkdownFiles) > 0 {
+		deps = append(deps,
+			namedTarget{"MarkdownFormat", func(ctx context.Context) error { return MarkdownFormat(ctx, cfg) }},
+		)
+	}
 	if len(deps) > 0 {
 		sg.SerialDeps(ctx, deps...)
 	}
@@ -72,12 +86,60 @@ func RunParallel(ctx context.Context, cfg config.Config) error {
 			namedTarget{"GoTest", func(ctx context.Context) error { return GoTest(ctx, cfg) }},
 			namedTarget{"GoVulncheck", func(ctx context.Context) error { return GoVulncheck(ctx, cfg) }},
 		)
+		if len(cfg.GoBinaries) > 0 {
+			deps = append(deps,
+				namedTarget{"GoBuild", func(ctx context.Context) error { return GoBuild(ctx, cfg) }},
+			)
+		}
 	}
 	if len(cfg.PythonModules) > 0 {
+		for _, checker := range cfg.PythonTypecheckers() {
+			switch checker {
+			case config.TypecheckerMypy:
+				deps = append(deps,
+					namedTarget{"PythonMypy", func(ctx context.Context) error { return PythonMypy(ctx, cfg) }},
+				)
+			case config.TypecheckerPyright:
+				deps = append(deps,
+					namedTarget{"PythonPyright", func(ctx context.Context) error { return PythonPyright(ctx, cfg) }},
+				)
+			case config.TypecheckerTy:
+				deps = append(deps,
+					namedTarget{"PythonTy", func(ctx context.Context) error { return PythonTy(ctx, cfg) }},
+				)
+			}
+		}
 		deps = append(deps,
-			namedTarget{"PythonMypy", func(ctx context.Context) error { return PythonMypy(ctx, cfg) }},
 			namedTarget{"PythonTest", func(ctx context.Context) error { return PythonTest(ctx, cfg) }},
 		)
+		if slices.ContainsFunc(cfg.PythonModules, func(module string) bool {
+			return uvLockCommitted(sg.FromGitRoot(module))
+		}) {
+			deps = append(deps,
+				namedTarget{"PythonLockCheck", func(ctx context.Context) error { return PythonLockCheck(ctx, cfg) }},
+			)
+		}
+	}
+	if len(cfg.TerraformModules) > 0 {
+		deps = append(deps,
+			namedTarget{"TerraformValidate", func(ctx context.Context) error { return TerraformValidate(ctx, cfg) }},
+			namedTarget{"TerraformLint", func(ctx context.Context) error { return TerraformLint(ctx, cfg) }},
+		)
+	}
+	if len(cfg.YAMLFiles) > 0 {
+		deps = append(deps,
+			namedTarget{"YamlLint", func(ctx context.Context) error { return YamlLint(ctx, cfg) }},
+		)
+	}
+	if len(cfg.Dockerfiles) > 0 {
+		deps = append(deps,
+			namedTarget{"DockerLint", func(ctx context.Context) error { return DockerLint(ctx, cfg) }},
+		)
+	}
+	if len(cfg.ActionsWorkflows) > 0 {
+		deps = append(deps,
+			namedTarget{"ActionsLint", func(ctx context.Context) error { return ActionsLint(ctx, cfg) }},
+		)
 	}
 	if len(deps) > 0 {
 		sg.Deps(ctx, deps...)
@@ -91,6 +153,9 @@ func RunParallel(ctx context.Context, cfg config.Config) error {
 // Defaults to GitHub if no platform is specified.
 func GenerateWorkflows(cfg config.Config) error {
 	cfg = cfg.WithDefaults()
+	if err := ValidateConfig(cfg); err != nil {
+		return err
+	}
 	for _, platform := range cfg.Platforms {
 		switch platform {
 		case config.PlatformGitLab:
@@ -108,16 +173,57 @@ func GenerateWorkflows(cfg config.Config) error {
 	return nil
 }
 
+// DetectDefaultBranch returns the branch origin/HEAD of the git repository in dir points at, or an empty
+// string if it cannot be detected. Shallow checkouts don't set origin/HEAD, so the remote is asked as a
+// fallback. Workflows are generated from the configured DefaultBranch only, so that they don't depend on
+// the network or the remote; sage-ci init and Doctor detect the branch.
+func DetectDefaultBranch(dir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	cmd.Dir = dir
+	if output, err := cmd.Output(); err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(string(output)), "origin/")
+	}
+	cmd = exec.CommandContext(ctx, "git", "ls-remote", "--symref", "origin", "HEAD")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	// E.g. "ref: refs/heads/main\tHEAD"
+	for line := range strings.Lines(string(output)) {
+		if ref, ok := strings.CutPrefix(line, "ref: refs/heads/"); ok {
+			branch, _, _ := strings.Cut(ref, "\t")
+			return branch
+		}
+	}
+	return ""
+}
+
 // --- Utility targets ---
 
-// UpdateSageCi updates the sage-ci dependency, regenerates Makefiles and workflows.
+// UpdateSageCi updates the sage-ci dependency to the latest version allowed by SageCiVersion,
+// regenerates Makefiles and workflows.
 func UpdateSageCi(ctx context.Context, cfg config.Config) error {
+	if err := ValidateConfig(cfg.WithDefaults()); err != nil {
+		return err
+	}
+
 	// Skip dependency update if running from the sage-ci repo itself.
 	if _, err := os.Stat(sg.FromGitRoot("cmd/sage-ci")); err == nil {
 		sg.Logger(ctx).Println("skipping sage-ci dependency update (running from sage-ci repo)")
 	} else {
-		sg.Logger(ctx).Println("updating sage-ci dependency...")
-		getCmd := sg.Command(ctx, "go", "get", "-u", "github.com/fredrikaverpil/sage-ci@latest")
+		version := cfg.WithDefaults().SageCiVersion
+		if version != "latest" {
+			var err error
+			if version, err = resolveSageCiVersion(ctx, version); err != nil {
+				return err
+			}
+		}
+		sg.Logger(ctx).Printf("updating sage-ci dependency to %s...", version)
+		getCmd := sg.Command(ctx, "go", "get", "-u", sageCiModule+"@"+version)
 		getCmd.Dir = sg.FromGitRoot(".sage")
 		if err := getCmd.Run(); err != nil {
 			return fmt.Errorf("update sage-ci dependency: %w", err)
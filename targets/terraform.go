@@ -0,0 +1,117 @@
+package targets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"github.com/fredrikaverpil/sage-ci/tools/sgopentofu"
+	"github.com/fredrikaverpil/sage-ci/tools/sgterraform"
+	"github.com/fredrikaverpil/sage-ci/tools/sgtflint"
+	"go.einride.tech/sage/sg"
+)
+
+// TerraformFormat runs terraform fmt for all configured Terraform modules.
+// In CI, formatting is only checked and the target fails on unformatted files.
+func TerraformFormat(ctx context.Context, cfg config.Config) error {
+	ctx, err := withTerraformCache(ctx)
+	if err != nil {
+		return err
+	}
+	for _, module := range selectModules(cfg.TerraformModules) {
+		if cfg.SkipTargets.ShouldSkip("TerraformFormat", module) {
+			continue
+		}
+		args := []string{"fmt", "-recursive"}
+		if os.Getenv("CI") != "" {
+			sg.Logger(ctx).Printf("checking terraform fmt in %s...", module)
+			args = append(args, "-check", "-diff")
+		} else {
+			sg.Logger(ctx).Printf("applying terraform fmt in %s...", module)
+		}
+		cmd := terraformCommand(ctx, cfg, args...)
+		moduleCommand(cmd, cfg, "TerraformFormat", module, 0)
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TerraformValidate runs terraform validate for all configured Terraform modules.
+// Modules are initialized without a backend, so no credentials are required.
+func TerraformValidate(ctx context.Context, cfg config.Config) error {
+	ctx, err := withTerraformCache(ctx)
+	if err != nil {
+		return err
+	}
+	for _, module := range selectModules(cfg.TerraformModules) {
+		if cfg.SkipTargets.ShouldSkip("TerraformValidate", module) {
+			continue
+		}
+		sg.Logger(ctx).Printf("running terraform init -backend=false in %s...", module)
+		initCmd := inModule(terraformCommand(ctx, cfg, "init", "-backend=false", "-input=false"), cfg, module)
+		if err := initCmd.Run(); err != nil {
+			return err
+		}
+		sg.Logger(ctx).Printf("running terraform validate in %s...", module)
+		cmd := terraformCommand(ctx, cfg, "validate")
+		moduleCommand(cmd, cfg, "TerraformValidate", module, 0)
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TerraformLint runs tflint for all configured Terraform modules.
+func TerraformLint(ctx context.Context, cfg config.Config) error {
+	ctx, err := withTerraformCache(ctx)
+	if err != nil {
+		return err
+	}
+	for _, module := range selectModules(cfg.TerraformModules) {
+		if cfg.SkipTargets.ShouldSkip("TerraformLint", module) {
+			continue
+		}
+		sg.Logger(ctx).Printf("running tflint --init in %s...", module)
+		initCmd := inModule(sgtflint.Command(ctx, "--init"), cfg, module)
+		if err := initCmd.Run(); err != nil {
+			return err
+		}
+		sg.Logger(ctx).Printf("running tflint in %s...", module)
+		cmd := sgtflint.Command(ctx, "--recursive")
+		moduleCommand(cmd, cfg, "TerraformLint", module, 0)
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// terraformCommand returns a terraform or tofu command depending on cfg.UseOpenTofu.
+func terraformCommand(ctx context.Context, cfg config.Config, args ...string) *exec.Cmd {
+	if cfg.UseOpenTofu {
+		return sgopentofu.Command(ctx, args...)
+	}
+	return sgterraform.Command(ctx, args...)
+}
+
+// withTerraformCache keeps provider and plugin caches in .sage/tools,
+// so that runs are reproducible offline once the caches are warm.
+func withTerraformCache(ctx context.Context) (context.Context, error) {
+	pluginCache := sg.FromToolsDir("terraform", "plugin-cache")
+	tflintPlugins := sg.FromToolsDir("tflint", "plugins")
+	for _, dir := range []string{pluginCache, tflintPlugins} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create terraform cache dir: %w", err)
+		}
+	}
+	return sg.ContextWithEnv(
+		ctx,
+		"TF_PLUGIN_CACHE_DIR="+pluginCache,
+		"TFLINT_PLUGIN_DIR="+tflintPlugins,
+	), nil
+}
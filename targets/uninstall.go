@@ -0,0 +1,129 @@
+package targets
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/fredrikaverpil/sage-ci/workflows/github"
+)
+
+// Artifact is a file or directory sage-ci, or Sage on its behalf, added to a project.
+type Artifact struct {
+	// Path is relative to the repository root, with forward slashes.
+	Path string `json:"path"`
+	// Kind is what the artifact is, e.g. "workflow", "Makefile" or "tools cache".
+	Kind string `json:"kind"`
+	// Custom reports whether the artifact is maintained by hand: an ejected workflow or a file in .sage
+	// sage-ci doesn't know. Custom artifacts are only removed when asked to.
+	Custom bool `json:"custom"`
+}
+
+// sageDirArtifacts are the kinds of the files and directories in .sage sage-ci and Sage create.
+var sageDirArtifacts = map[string]string{
+	".gitignore":        "setup",
+	"go.mod":            "setup",
+	"go.sum":            "setup",
+	"sagefile.go":       "setup",
+	"sage-ci.yaml":      "config",
+	"sage-ci.toml":      "config",
+	"targets.gen.go":    "generated targets",
+	previousVersionFile: "upgrade state",
+	"bin":               "sagefile binary",
+	"build":             "build cache",
+	"tools":             "tools cache",
+	"mirror":            "tools mirror",
+}
+
+// makefileHeader starts the first line of Makefiles generated by Sage.
+const makefileHeader = "# Code generated by go.einride.tech/sage. DO NOT EDIT."
+
+// FindArtifacts returns the artifacts of sage-ci in the repository at root: the generated sage-ci-*.yml
+// workflows and Makefiles, recognized by their headers, and the contents of .sage.
+func FindArtifacts(root string) ([]Artifact, error) {
+	var artifacts []Artifact
+
+	workflows, err := filepath.Glob(filepath.Join(root, ".github", "workflows", "sage-ci-*.yml"))
+	if err != nil {
+		return nil, err
+	}
+	for _, workflow := range workflows {
+		content, err := os.ReadFile(workflow)
+		if err != nil {
+			return nil, fmt.Errorf("read workflow: %w", err)
+		}
+		artifact := Artifact{Path: ".github/workflows/" + filepath.Base(workflow), Kind: "workflow"}
+		if !github.IsGenerated(content) {
+			artifact.Kind, artifact.Custom = "ejected workflow", true
+		}
+		artifacts = append(artifacts, artifact)
+	}
+
+	makefiles, err := listMakefiles(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, makefile := range makefiles {
+		content, err := os.ReadFile(filepath.Join(root, makefile))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("read %s: %w", makefile, err)
+		}
+		if bytes.HasPrefix(content, []byte(makefileHeader)) {
+			artifacts = append(artifacts, Artifact{Path: makefile, Kind: "Makefile"})
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, ".sage"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read .sage: %w", err)
+	}
+	for _, entry := range entries {
+		artifact := Artifact{Path: ".sage/" + entry.Name(), Kind: sageDirArtifacts[entry.Name()]}
+		if artifact.Kind == "" {
+			artifact.Kind, artifact.Custom = "custom", true
+		}
+		if entry.IsDir() {
+			artifact.Path += "/"
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, nil
+}
+
+// listMakefiles returns the Makefiles known to git in the repository at root, relative to root.
+func listMakefiles(root string) ([]string, error) {
+	cmd := exec.Command("git", "ls-files", "--cached", "--others", "--exclude-standard", "--", "Makefile", "**/Makefile")
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("list Makefiles: %w", err)
+	}
+	var makefiles []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		makefiles = append(makefiles, scanner.Text())
+	}
+	return makefiles, nil
+}
+
+// RemoveArtifacts removes the artifacts from the repository at root. .sage is removed too if it ends up empty.
+func RemoveArtifacts(root string, artifacts []Artifact) error {
+	for _, artifact := range artifacts {
+		if err := os.RemoveAll(filepath.Join(root, filepath.FromSlash(artifact.Path))); err != nil {
+			return fmt.Errorf("remove %s: %w", artifact.Path, err)
+		}
+	}
+	sageDir := filepath.Join(root, ".sage")
+	if entries, err := os.ReadDir(sageDir); err == nil && len(entries) == 0 {
+		if err := os.Remove(sageDir); err != nil {
+			return fmt.Errorf("remove .sage: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package targets
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindArtifacts(t *testing.T) {
+	root := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, output)
+	}
+	files := map[string]string{
+		".github/workflows/sage-ci-go-ci.yml": "# Generated by sage-ci\nname: Go\n",
+		".github/workflows/sage-ci-stale.yml": "# Ejected from sage-ci, maintained in this repository.\nname: Stale\n",
+		".github/workflows/release.yml":       "name: Release\n",
+		"Makefile":                            makefileHeader + "\n",
+		"docs/Makefile":                       "html:\n\tsphinx-build . _build\n",
+		".sage/go.mod":                        "module sage\n",
+		".sage/sagefile.go":                   "package main\n",
+		".sage/targets.gen.go":                "// Code generated by sage-ci. DO NOT EDIT.\n",
+		".sage/custom.go":                     "package main\n",
+		".sage/tools/uv/0.6.12/bin/uv":        "",
+	}
+	for path, content := range files {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	artifacts, err := FindArtifacts(root)
+	if err != nil {
+		t.Fatalf("FindArtifacts() failed: %v", err)
+	}
+	want := map[string]Artifact{
+		".github/workflows/sage-ci-go-ci.yml": {Kind: "workflow"},
+		".github/workflows/sage-ci-stale.yml": {Kind: "ejected workflow", Custom: true},
+		"Makefile":                            {Kind: "Makefile"},
+		".sage/custom.go":                     {Kind: "custom", Custom: true},
+		".sage/go.mod":                        {Kind: "setup"},
+		".sage/sagefile.go":                   {Kind: "setup"},
+		".sage/targets.gen.go":                {Kind: "generated targets"},
+		".sage/tools/":                        {Kind: "tools cache"},
+	}
+	got := map[string]Artifact{}
+	for _, artifact := range artifacts {
+		got[artifact.Path] = Artifact{Kind: artifact.Kind, Custom: artifact.Custom}
+	}
+	if len(got) != len(want) {
+		t.Errorf("FindArtifacts() = %v, want %v", got, want)
+	}
+	for path, artifact := range want {
+		if got[path] != artifact {
+			t.Errorf("FindArtifacts()[%s] = %+v, want %+v", path, got[path], artifact)
+		}
+	}
+
+	var generated []Artifact
+	for _, artifact := range artifacts {
+		if !artifact.Custom {
+			generated = append(generated, artifact)
+		}
+	}
+	if err := RemoveArtifacts(root, generated); err != nil {
+		t.Fatalf("RemoveArtifacts() failed: %v", err)
+	}
+	for _, path := range []string{
+		".github/workflows/sage-ci-stale.yml", ".github/workflows/release.yml", "docs/Makefile", ".sage/custom.go",
+	} {
+		if _, err := os.Stat(filepath.Join(root, path)); err != nil {
+			t.Errorf("%s was removed: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, ".sage", "tools")); !os.IsNotExist(err) {
+		t.Errorf(".sage/tools was not removed: %v", err)
+	}
+
+	if err := RemoveArtifacts(root, []Artifact{{Path: ".sage/custom.go"}}); err != nil {
+		t.Fatalf("RemoveArtifacts() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".sage")); !os.IsNotExist(err) {
+		t.Errorf("empty .sage was not removed: %v", err)
+	}
+}
@@ -0,0 +1,466 @@
+package targets
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"go.einride.tech/sage/sg"
+)
+
+const sageCiModule = "github.com/fredrikaverpil/sage-ci"
+
+// previousVersionFile records the sage-ci version before the last upgrade, relative to .sage.
+const previousVersionFile = "sage-ci.previous"
+
+// UpgradeSageCi upgrades sage-ci in .sage/go.mod to version, a version or constraint such as
+// "v0.5" or "latest", defaulting to the configured SageCiVersion. It prints the CHANGELOG entries
+// between the current and the new version and the changes to generated files, and keeps the upgrade
+// only if confirmed on the terminal. The previous version is recorded for RollbackSageCi.
+func UpgradeSageCi(ctx context.Context, cfg config.Config, version string) error {
+	return upgradeSageCi(ctx, cfg, version, false)
+}
+
+// PreviewUpgradeSageCi prints what UpgradeSageCi would change, without changing anything.
+// The upgrade is applied to a copy of the work tree.
+func PreviewUpgradeSageCi(ctx context.Context, cfg config.Config, version string) error {
+	return upgradeSageCi(ctx, cfg, version, true)
+}
+
+// RollbackSageCi restores the sage-ci version recorded by the last UpgradeSageCi.
+func RollbackSageCi(ctx context.Context, cfg config.Config) error {
+	if err := checkNotSageCiRepo(); err != nil {
+		return err
+	}
+	path := sg.FromGitRoot(".sage", previousVersionFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.New("no previous sage-ci version recorded, nothing to roll back")
+	} else if err != nil {
+		return fmt.Errorf("read previous sage-ci version: %w", err)
+	}
+	previous := strings.TrimSpace(string(data))
+	current, err := currentSageCiVersion(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Rolling back sage-ci from %s to %s\n", current, previous)
+	root := sg.FromGitRoot()
+	before, err := snapshotGeneratedFiles(root)
+	if err != nil {
+		return err
+	}
+	if err := applySageCiVersion(ctx, cfg, root, previous); err != nil {
+		return errors.Join(err, before.restore(root))
+	}
+	after, err := snapshotGeneratedFiles(root)
+	if err != nil {
+		return err
+	}
+	if err := writeDiff(os.Stdout, before, after); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("remove previous sage-ci version: %w", err)
+	}
+	return nil
+}
+
+// upgradeSageCi upgrades sage-ci, or with preview set only shows the upgrade of a copy of the work tree.
+func upgradeSageCi(ctx context.Context, cfg config.Config, version string, preview bool) error {
+	if err := checkNotSageCiRepo(); err != nil {
+		return err
+	}
+	if version == "" {
+		version = cfg.WithDefaults().SageCiVersion
+	}
+	current, err := currentSageCiVersion(ctx)
+	if err != nil {
+		return err
+	}
+	target, err := resolveSageCiVersion(ctx, version)
+	if err != nil {
+		return err
+	}
+	if target == current {
+		fmt.Printf("sage-ci is already at %s\n", current)
+		return nil
+	}
+	fmt.Printf("Upgrading sage-ci from %s to %s\n\n", current, target)
+	if changes, err := sageCiChangelog(ctx, current, target); err != nil {
+		fmt.Printf("Could not read the changelog: %v\n\n", err)
+	} else if changes != "" {
+		fmt.Println(changes)
+	}
+
+	root := sg.FromGitRoot()
+	if preview {
+		if root, err = copyWorkTree(root); err != nil {
+			return err
+		}
+		defer func() { _ = os.RemoveAll(root) }()
+	}
+	before, err := snapshotGeneratedFiles(root)
+	if err != nil {
+		return err
+	}
+	if err := applySageCiVersion(ctx, cfg, root, target); err != nil {
+		if preview {
+			return err
+		}
+		return errors.Join(err, before.restore(root))
+	}
+	after, err := snapshotGeneratedFiles(root)
+	if err != nil {
+		return err
+	}
+	if err := writeDiff(os.Stdout, before, after); err != nil {
+		if preview {
+			return err
+		}
+		return errors.Join(err, before.restore(root))
+	}
+	if preview {
+		return nil
+	}
+	if !confirm(fmt.Sprintf("Keep sage-ci %s?", target)) {
+		// Files generated for the new version which didn't exist before are removed too.
+		if err := before.restore(root); err != nil {
+			return err
+		}
+		fmt.Printf("Kept sage-ci %s\n", current)
+		return nil
+	}
+	if err := os.WriteFile(sg.FromGitRoot(".sage", previousVersionFile), []byte(current+"\n"), 0o644); err != nil {
+		return fmt.Errorf("record previous sage-ci version: %w", err)
+	}
+	fmt.Printf("Upgraded sage-ci to %s, roll back with: sage-ci upgrade -rollback\n", target)
+	return nil
+}
+
+// checkNotSageCiRepo fails when run from the sage-ci repository, which has no sage-ci dependency.
+func checkNotSageCiRepo() error {
+	if _, err := os.Stat(sg.FromGitRoot("cmd/sage-ci")); err == nil {
+		return errors.New("sage-ci is not a dependency of the sage-ci repository itself")
+	}
+	return nil
+}
+
+// currentSageCiVersion returns the sage-ci version required by .sage/go.mod.
+func currentSageCiVersion(ctx context.Context) (string, error) {
+	output, err := goCommandOutput(ctx, "list", "-m", "-json", sageCiModule)
+	if err != nil {
+		return "", fmt.Errorf("get current sage-ci version: %w", err)
+	}
+	var module struct{ Version string }
+	if err := json.Unmarshal(output, &module); err != nil {
+		return "", fmt.Errorf("parse go list output: %w", err)
+	}
+	return module.Version, nil
+}
+
+// resolveSageCiVersion returns the highest sage-ci version allowed by the constraint.
+func resolveSageCiVersion(ctx context.Context, constraint string) (string, error) {
+	c, err := config.ParseVersionConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+	if c.Exact() {
+		// The version may be unlisted, e.g. a retracted version; go get reports if it doesn't exist.
+		v, _ := config.ParseVersion(constraint)
+		return v.String(), nil
+	}
+	if c.String() == "latest" {
+		// Let go resolve latest, which falls back to a pseudo-version without tagged versions.
+		output, err := goCommandOutput(ctx, "list", "-m", "-json", sageCiModule+"@latest")
+		if err != nil {
+			return "", fmt.Errorf("get latest sage-ci version: %w", err)
+		}
+		var module struct{ Version string }
+		if err := json.Unmarshal(output, &module); err != nil {
+			return "", fmt.Errorf("parse go list output: %w", err)
+		}
+		return module.Version, nil
+	}
+	output, err := goCommandOutput(ctx, "list", "-m", "-versions", "-json", sageCiModule)
+	if err != nil {
+		return "", fmt.Errorf("list sage-ci versions: %w", err)
+	}
+	var module struct{ Versions []string }
+	if err := json.Unmarshal(output, &module); err != nil {
+		return "", fmt.Errorf("parse go list output: %w", err)
+	}
+	version, ok := c.Latest(module.Versions)
+	if !ok {
+		return "", fmt.Errorf("no sage-ci version matches %s", c)
+	}
+	return version, nil
+}
+
+// sageCiChangelog returns the CHANGELOG.md entries of the versions after from, up to and including to.
+// When downgrading, it returns the entries being reverted.
+func sageCiChangelog(ctx context.Context, from, to string) (string, error) {
+	output, err := goCommandOutput(ctx, "mod", "download", "-json", sageCiModule+"@"+to)
+	if err != nil {
+		return "", fmt.Errorf("download sage-ci %s: %w", to, err)
+	}
+	var module struct{ Dir string }
+	if err := json.Unmarshal(output, &module); err != nil {
+		return "", fmt.Errorf("parse go mod download output: %w", err)
+	}
+	changelog, err := os.ReadFile(filepath.Join(module.Dir, "CHANGELOG.md"))
+	if err != nil {
+		return "", err
+	}
+	return changelogBetween(string(changelog), from, to), nil
+}
+
+// changelogBetween returns the sections of a release-please changelog, headed
+// "## [1.2.3](...)" or "## 1.2.3", for the versions between from and to, excluding the lower one.
+func changelogBetween(changelog, from, to string) string {
+	lower, lowerErr := config.ParseVersion(from)
+	upper, upperErr := config.ParseVersion(to)
+	if upperErr != nil {
+		return ""
+	}
+	if lowerErr == nil && lower.Compare(upper) > 0 {
+		lower, upper = upper, lower
+	}
+	var b strings.Builder
+	var include bool
+	for line := range strings.Lines(changelog) {
+		if heading, ok := strings.CutPrefix(line, "## "); ok {
+			heading = strings.TrimPrefix(heading, "[")
+			end := strings.IndexAny(heading, "]( \n")
+			if end < 0 {
+				end = len(heading)
+			}
+			v, err := config.ParseVersion(heading[:end])
+			include = err == nil && v.Compare(upper) <= 0 && (lowerErr != nil || v.Compare(lower) > 0)
+		}
+		if include {
+			b.WriteString(line)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// applySageCiVersion changes sage-ci in .sage/go.mod of the git work tree at root to version and
+// regenerates targets.gen.go, the Makefiles and the workflows. The Makefiles and workflows are
+// generated by the new version.
+func applySageCiVersion(ctx context.Context, cfg config.Config, root, version string) error {
+	sageDir := filepath.Join(root, ".sage")
+	for _, args := range [][]string{
+		{"get", sageCiModule + "@" + version},
+		{"mod", "tidy"},
+	} {
+		cmd := sg.Command(ctx, "go", args...)
+		cmd.Dir = sageDir
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("go %s: %w", strings.Join(args, " "), err)
+		}
+	}
+	if err := GenerateTargetsFile(cfg, sageDir); err != nil {
+		return fmt.Errorf("generate targets file: %w", err)
+	}
+	for _, args := range [][]string{
+		{"run", "."},
+		{"run", ".", "GenerateWorkflows"},
+	} {
+		cmd := sg.Command(ctx, "go", args...)
+		cmd.Dir = sageDir
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("go %s: %w", strings.Join(args, " "), err)
+		}
+	}
+	return nil
+}
+
+// goCommandOutput runs go with args in .sage and returns its output.
+func goCommandOutput(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := sg.Command(ctx, "go", args...)
+	cmd.Dir = sg.FromGitRoot(".sage")
+	cmd.Stdout = nil
+	return cmd.Output()
+}
+
+// fileSnapshot maps paths relative to the git root to their content, nil for missing files.
+type fileSnapshot map[string][]byte
+
+// snapshotGeneratedFiles reads the files an upgrade may change in the git work tree at root:
+// .sage/go.mod, .sage/go.sum, targets.gen.go, the Makefiles and the sage-ci workflows.
+func snapshotGeneratedFiles(root string) (fileSnapshot, error) {
+	paths := []string{".sage/go.mod", ".sage/go.sum", ".sage/targets.gen.go"}
+	workflows, err := filepath.Glob(filepath.Join(root, ".github", "workflows", "sage-ci-*.yml"))
+	if err != nil {
+		return nil, err
+	}
+	for _, workflow := range workflows {
+		rel, err := filepath.Rel(root, workflow)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	makefiles, err := listMakefiles(root)
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, makefiles...)
+	snapshot := fileSnapshot{}
+	for _, path := range paths {
+		data, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("snapshot %s: %w", path, err)
+		}
+		snapshot[path] = data
+	}
+	return snapshot, nil
+}
+
+// restore writes the snapshot back to the git work tree at root, removing files which were missing
+// when it was taken and sage-ci workflows created since.
+func (s fileSnapshot) restore(root string) error {
+	workflows, err := filepath.Glob(filepath.Join(root, ".github", "workflows", "sage-ci-*.yml"))
+	if err != nil {
+		return err
+	}
+	for _, workflow := range workflows {
+		rel, err := filepath.Rel(root, workflow)
+		if err != nil {
+			return err
+		}
+		if _, ok := s[filepath.ToSlash(rel)]; !ok {
+			if err := os.Remove(workflow); err != nil {
+				return fmt.Errorf("restore %s: %w", rel, err)
+			}
+		}
+	}
+	for path, data := range s {
+		if data == nil {
+			if err := os.Remove(filepath.Join(root, path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("restore %s: %w", path, err)
+			}
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(root, path), data, 0o644); err != nil {
+			return fmt.Errorf("restore %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// copyWorkTree copies the files of the git work tree at root which aren't ignored into a new git
+// repository in a temporary directory, so that generating files there leaves root untouched.
+func copyWorkTree(root string) (dir string, err error) {
+	cmd := exec.Command("git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("list files to copy: %w", err)
+	}
+	if dir, err = os.MkdirTemp("", "sage-ci-preview-*"); err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(dir)
+		}
+	}()
+	for path := range strings.SplitSeq(strings.TrimSuffix(string(output), "\x00"), "\x00") {
+		src, dst := filepath.Join(root, path), filepath.Join(dir, path)
+		info, err := os.Lstat(src)
+		if errors.Is(err, os.ErrNotExist) {
+			// Deleted in the work tree.
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return "", err
+		}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(src)
+			if err != nil {
+				return "", err
+			}
+			if err := os.Symlink(target, dst); err != nil {
+				return "", err
+			}
+		case info.Mode().IsRegular():
+			data, err := os.ReadFile(src)
+			if err != nil {
+				return "", err
+			}
+			if err := os.WriteFile(dst, data, info.Mode().Perm()); err != nil {
+				return "", err
+			}
+		}
+	}
+	// The sagefile finds the files it generates from the git root.
+	if err := exec.Command("git", "init", "--quiet", dir).Run(); err != nil {
+		return "", fmt.Errorf("git init %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// writeDiff writes a unified diff from before to after, using git diff.
+func writeDiff(w io.Writer, before, after fileSnapshot) error {
+	dir, err := os.MkdirTemp("", "sage-ci-diff-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	for name, snapshot := range map[string]fileSnapshot{"before": before, "after": after} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+			return err
+		}
+		for path, data := range snapshot {
+			if data == nil {
+				continue
+			}
+			p := filepath.Join(dir, name, filepath.FromSlash(path))
+			if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(p, data, 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	cmd := exec.Command("git", "diff", "--no-index", "--src-prefix=", "--dst-prefix=", "before", "after")
+	cmd.Dir = dir
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	// git diff --no-index exits with 1 when the files differ.
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return fmt.Errorf("diff generated files: %w", err)
+		}
+		return nil
+	}
+	fmt.Fprintln(w, "No changes to generated files.")
+	return nil
+}
+
+// confirm asks question on the terminal. Without a terminal, e.g. in CI, it refuses.
+func confirm(question string) bool {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		fmt.Printf("%s No terminal to confirm on, answering no.\n", question)
+		return false
+	}
+	fmt.Printf("%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return slices.Contains([]string{"y", "yes"}, strings.ToLower(strings.TrimSpace(answer)))
+}
@@ -0,0 +1,110 @@
+package targets
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChangelogBetween(t *testing.T) {
+	changelog := `# Changelog
+
+## [0.4.0](https://github.com/fredrikaverpil/sage-ci/compare/v0.3.0...v0.4.0) (2026-01-04)
+
+* **tool:** add tree-sitter-cli
+
+## [0.3.0](https://github.com/fredrikaverpil/sage-ci/compare/v0.2.0...v0.3.0) (2026-01-04)
+
+* **tool:** add ts_query_ls
+
+## 0.2.0 (2026-01-04)
+
+* skipping targets also skips workflow jobs
+`
+	want := `## [0.4.0](https://github.com/fredrikaverpil/sage-ci/compare/v0.3.0...v0.4.0) (2026-01-04)
+
+* **tool:** add tree-sitter-cli
+
+## [0.3.0](https://github.com/fredrikaverpil/sage-ci/compare/v0.2.0...v0.3.0) (2026-01-04)
+
+* **tool:** add ts_query_ls`
+	if got := changelogBetween(changelog, "v0.2.0", "v0.4.0"); got != want {
+		t.Errorf("changelogBetween(v0.2.0, v0.4.0) =\n%s\nwant\n%s", got, want)
+	}
+	if got := changelogBetween(changelog, "v0.4.0", "v0.2.0"); got != want {
+		t.Errorf("changelogBetween(v0.4.0, v0.2.0) =\n%s\nwant the reverted entries\n%s", got, want)
+	}
+	if got := changelogBetween(changelog, "v0.3.0", "v0.3.0"); got != "" {
+		t.Errorf("changelogBetween(v0.3.0, v0.3.0) = %q, want empty", got)
+	}
+	if got := changelogBetween(changelog, "v0.0.0-20260101000000-abcdef123456", "v0.2.0"); got == "" {
+		t.Error("changelogBetween() from a pseudo-version is empty, want entries up to v0.2.0")
+	}
+}
+
+func TestCopyWorkTree(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		".gitignore":       "tools/\n",
+		".sage/go.mod":     "module sage\n",
+		".sage/untracked":  "untracked\n",
+		".sage/tools/tool": "ignored\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"init", "--quiet"}, {"add", ".gitignore", ".sage/go.mod"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	dir, err := copyWorkTree(root)
+	if err != nil {
+		t.Fatalf("copyWorkTree() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	for path, want := range map[string]bool{".sage/go.mod": true, ".sage/untracked": true, ".sage/tools/tool": false} {
+		if _, err := os.Stat(filepath.Join(dir, path)); (err == nil) != want {
+			t.Errorf("%s copied = %t, want %t", path, err == nil, want)
+		}
+	}
+	// Sage finds the git root of the copy, not of root.
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = filepath.Join(dir, ".sage")
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(string(output)), dir; got != want {
+		if resolved, _ := filepath.EvalSymlinks(want); got != resolved {
+			t.Errorf("git root of the copy = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestConfirmWithoutTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	if _, err := w.WriteString("y\n"); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = stdin })
+	if confirm("Keep?") {
+		t.Error("confirm() without a terminal = true, want false")
+	}
+}
@@ -0,0 +1,31 @@
+package targets
+
+import (
+	"fmt"
+
+	"github.com/fredrikaverpil/sage-ci/config"
+	"github.com/fredrikaverpil/sage-ci/workflows/github"
+	"go.einride.tech/sage/sg"
+)
+
+// TargetNames returns the names of all targets sage-ci can generate.
+// These are the keys accepted by [config.Config.SkipTargets].
+func TargetNames() []string {
+	names := make([]string, 0, len(allTargets))
+	for _, t := range allTargets {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// ValidateConfig validates cfg against the repository and the known target and workflow names.
+func ValidateConfig(cfg config.Config) error {
+	workflows, err := github.WorkflowNames()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(sg.FromGitRoot(), TargetNames(), workflows); err != nil {
+		return fmt.Errorf("invalid sage-ci config:\n%w", err)
+	}
+	return nil
+}
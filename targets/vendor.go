@@ -0,0 +1,44 @@
+package targets
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/fredrikaverpil/sage-ci/tools/sgmdformat"
+	"github.com/fredrikaverpil/sage-ci/tools/sgtools"
+	"github.com/fredrikaverpil/sage-ci/tools/sgyamllint"
+	"go.einride.tech/sage/sg"
+)
+
+// VendorTools downloads the release archives of the tools sage-ci installs for goos and goarch into dir,
+// for installing the tools without internet access. dir defaults to .sage/mirror, and goos and goarch
+// default to the host platform.
+func VendorTools(ctx context.Context, dir, goos, goarch string) error {
+	if dir == "" {
+		dir = sg.FromSageDir("mirror")
+	}
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", dir, err)
+	}
+	archives, err := sgtools.Vendor(ctx, dir, goos, goarch)
+	if err != nil {
+		return err
+	}
+	for _, archive := range archives {
+		sg.Logger(ctx).Printf("vendored %s", archive)
+	}
+	sg.Logger(ctx).Printf("install tools from the archives with %s=%s, or serve %s and set %s to its URL",
+		sgtools.MirrorEnv, dir, dir, sgtools.MirrorEnv)
+	sg.Logger(ctx).Printf("uv, %s and %s are not vendored, see Installing tools without internet access in the README",
+		sgmdformat.Name, sgyamllint.Name)
+	return nil
+}
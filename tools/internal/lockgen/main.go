@@ -0,0 +1,52 @@
+// Command lockgen refreshes tools/internal/sgdownload/tools.lock with the SHA-256 digests of the
+// release archives of every tool, for every platform the tool is released for.
+// Run it from the repository root after changing a tool version:
+//
+//	go run ./tools/internal/lockgen
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgdownload"
+	"github.com/fredrikaverpil/sage-ci/tools/sgtools"
+)
+
+func main() {
+	output := flag.String("o", sgdownload.LockFile, "lock file to write")
+	flag.Parse()
+	log.SetFlags(0)
+
+	var checksums []sgdownload.Checksum
+	for _, t := range sgtools.Tools() {
+		for _, platform := range sgtools.Platforms {
+			goos, goarch, _ := strings.Cut(platform, "/")
+			url, err := t.DownloadURL(goos, goarch)
+			if err != nil {
+				// The tool isn't released for the platform.
+				continue
+			}
+			digest, err := sgdownload.Download(context.Background(), url, io.Discard)
+			if errors.Is(err, sgdownload.ErrNotFound) {
+				// Tools without a digest refuse to install, so the tool's platforms must be fixed instead.
+				log.Fatalf("%s %s: no release for %s at %s", t.Name, t.Version, platform, url)
+			} else if err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("%s %s %s sha256:%s", t.Name, t.Version, platform, digest)
+			checksums = append(checksums, sgdownload.Checksum{
+				Tool: t.Name, Version: t.Version, Platform: platform, SHA256: digest,
+			})
+		}
+	}
+	if err := os.WriteFile(*output, sgdownload.FormatLock(checksums), 0o644); err != nil {
+		log.Fatal(fmt.Errorf("write lock file: %w", err))
+	}
+}
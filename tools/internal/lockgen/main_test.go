@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgdownload"
+	"github.com/fredrikaverpil/sage-ci/tools/sgtools"
+)
+
+// TestLockIsCurrent fails when tools.lock lacks a digest for a tool version and platform, e.g. in a
+// Renovate pull request changing a tool version, since the tool would then refuse to install.
+func TestLockIsCurrent(t *testing.T) {
+	checksums, err := sgdownload.Locked()
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := map[string]bool{}
+	for _, c := range checksums {
+		locked[c.Tool+" "+c.Version+" "+c.Platform] = true
+	}
+	released := map[string]bool{}
+	for _, tool := range sgtools.Tools() {
+		for _, platform := range sgtools.Platforms {
+			goos, goarch, _ := strings.Cut(platform, "/")
+			if _, err := tool.DownloadURL(goos, goarch); err != nil {
+				continue
+			}
+			key := tool.Name + " " + tool.Version + " " + platform
+			released[key] = true
+			if !locked[key] {
+				t.Errorf("%s is missing from %s: run go run ./tools/internal/lockgen", key, sgdownload.LockFile)
+			}
+		}
+	}
+	for key := range locked {
+		if !released[key] {
+			t.Errorf("%s is locked in %s, but is no longer a tool release: run go run ./tools/internal/lockgen",
+				key, sgdownload.LockFile)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package sgdownload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"go.einride.tech/sage/sg"
+)
+
+// MirrorEnv is the environment variable pointing to a mirror of the release archives, either a base URL
+// or a local directory. Archives are looked up as <mirror>/<tool>/<version>/<archive name>, the layout
+// written by Save, instead of at their release URLs.
+const MirrorEnv = "SAGE_CI_TOOLS_MIRROR"
+
+// ErrNotFound is returned by Download when there is nothing at the location.
+var ErrNotFound = errors.New("not found")
+
+// Download writes the content at location, a URL or a local path, to w and returns its hex-encoded
+// SHA-256 digest.
+func Download(ctx context.Context, location string, w io.Writer) (string, error) {
+	r, err := open(ctx, location)
+	if err != nil {
+		return "", fmt.Errorf("download %s: %w", location, err)
+	}
+	defer r.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), r); err != nil {
+		return "", fmt.Errorf("download %s: %w", location, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// open opens location, a URL or a local path.
+func open(ctx context.Context, location string) (io.ReadCloser, error) {
+	if !isURL(location) {
+		f, err := os.Open(location)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return f, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		resp.Body.Close()
+		return nil, errors.New(resp.Status)
+	}
+}
+
+// isURL reports whether location is an HTTP(S) URL rather than a local path.
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// source returns where to download the release archive of tool at version from:
+// url, or its location in the mirror set by MirrorEnv.
+func source(tool, version, url string) string {
+	mirror := os.Getenv(MirrorEnv)
+	if mirror == "" {
+		return url
+	}
+	if isURL(mirror) {
+		return strings.TrimSuffix(mirror, "/") + "/" + path.Join(tool, version, path.Base(url))
+	}
+	return filepath.Join(mirror, tool, version, path.Base(url))
+}
+
+// fetch writes the release archive of tool at version from location to w, and verifies it against
+// the digest for platform in tools.lock. Nothing is downloaded without a digest to verify against.
+func fetch(ctx context.Context, tool, version, platform, location string, w io.Writer) error {
+	checksums, err := Locked()
+	if err != nil {
+		return err
+	}
+	want, err := lookup(checksums, tool, version, platform)
+	if err != nil {
+		return err
+	}
+	sg.Logger(ctx).Printf("fetching %s ...", location)
+	got, err := Download(ctx, location, w)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("checksum mismatch for %s: got sha256:%s, want sha256:%s", location, got, want)
+	}
+	return nil
+}
+
+// Fetch downloads the release archive of tool at version for the host platform, from url or the mirror
+// set by MirrorEnv, to a temporary file verified against tools.lock. The returned function removes the file.
+func Fetch(ctx context.Context, tool, version, url string) (string, func(), error) {
+	f, err := os.CreateTemp("", tool+"-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("create temporary file: %w", err)
+	}
+	cleanup := func() { _ = os.Remove(f.Name()) }
+	location := source(tool, version, url)
+	err = fetch(ctx, tool, version, runtime.GOOS+"/"+runtime.GOARCH, location, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if errors.Is(err, ErrNotFound) && location != url {
+		err = fmt.Errorf("%w, add it to the mirror with sage-ci tools vendor", err)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return f.Name(), cleanup, nil
+}
+
+// Save downloads the release archive of tool at version for platform from url into dir, in the mirror
+// layout read through MirrorEnv, verified against tools.lock. An archive already in dir is kept if it
+// matches tools.lock. Save returns the path of the archive.
+func Save(ctx context.Context, tool, version, platform, url, dir string) (string, error) {
+	dest := filepath.Join(dir, tool, version, path.Base(url))
+	if _, err := os.Stat(dest); err == nil {
+		if err := fetch(ctx, tool, version, platform, dest, io.Discard); err == nil {
+			return dest, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return "", fmt.Errorf("create %s: %w", filepath.Dir(dest), err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(dest), ".download-*")
+	if err != nil {
+		return "", fmt.Errorf("create temporary file: %w", err)
+	}
+	err = fetch(ctx, tool, version, platform, url, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), dest)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return dest, nil
+}
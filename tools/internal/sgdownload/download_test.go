@@ -0,0 +1,175 @@
+package sgdownload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFetch(t *testing.T) {
+	archive := []byte("release archive")
+	sum := sha256.Sum256(archive)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tool.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	t.Cleanup(server.Close)
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+
+	for _, tt := range []struct {
+		name    string
+		lock    []Checksum
+		wantErr string
+	}{
+		{name: "matching digest", lock: []Checksum{{"tool", "1.0.0", platform, hex.EncodeToString(sum[:])}}},
+		{
+			name:    "unlocked tool",
+			lock:    []Checksum{{"other", "1.0.0", platform, strings.Repeat("a", 64)}},
+			wantErr: "missing from",
+		},
+		{
+			name:    "mismatching digest",
+			lock:    []Checksum{{"tool", "1.0.0", platform, strings.Repeat("a", 64)}},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name:    "stale lock",
+			lock:    []Checksum{{"tool", "0.9.0", platform, hex.EncodeToString(sum[:])}},
+			wantErr: "missing from",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			setLock(t, tt.lock...)
+
+			path, cleanup, err := Fetch(context.Background(), "tool", "1.0.0", server.URL+"/tool.zip")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() failed: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil || string(got) != string(archive) {
+				t.Errorf("Fetch() wrote %q, %v, want %q", got, err, archive)
+			}
+			cleanup()
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("cleanup() left %s", path)
+			}
+		})
+	}
+
+	if _, err := Download(context.Background(), server.URL+"/missing.zip", io.Discard); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Download() of a missing file = %v, want ErrNotFound", err)
+	}
+}
+
+// setLock replaces the embedded lock file with checksums for the duration of the test.
+func setLock(t *testing.T, checksums ...Checksum) {
+	t.Helper()
+	original := lockFile
+	lockFile = FormatLock(checksums)
+	t.Cleanup(func() { lockFile = original })
+}
+
+// digest returns the hex-encoded SHA-256 digest of data.
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFetchFromMirror(t *testing.T) {
+	archive := []byte("mirrored archive")
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	setLock(t,
+		Checksum{"tool", "1.0.0", platform, digest(archive)},
+		Checksum{"tool", "2.0.0", platform, digest([]byte("unmirrored archive"))},
+		Checksum{"tampered", "1.0.0", platform, digest(archive)},
+	)
+	upstream := "https://example.com/releases/download/v1.0.0/tool_linux_amd64.tar.gz"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mirror/tool/1.0.0/tool_linux_amd64.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	t.Cleanup(server.Close)
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "tool", "1.0.0"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tool", "1.0.0", "tool_linux_amd64.tar.gz"), archive, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "tampered", "1.0.0"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tampered := filepath.Join(dir, "tampered", "1.0.0", "tool_linux_amd64.tar.gz")
+	if err := os.WriteFile(tampered, []byte("tampered archive"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mirror := range []string{server.URL + "/mirror/", dir} {
+		t.Setenv(MirrorEnv, mirror)
+		path, cleanup, err := Fetch(context.Background(), "tool", "1.0.0", upstream)
+		if err != nil {
+			t.Fatalf("Fetch() from mirror %s failed: %v", mirror, err)
+		}
+		if got, err := os.ReadFile(path); err != nil || string(got) != string(archive) {
+			t.Errorf("Fetch() from mirror %s wrote %q, %v, want %q", mirror, got, err, archive)
+		}
+		cleanup()
+
+		_, _, err = Fetch(context.Background(), "tool", "2.0.0", upstream)
+		if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "sage-ci tools vendor") {
+			t.Errorf("Fetch() of a version missing from mirror %s = %v, want ErrNotFound with a hint", mirror, err)
+		}
+	}
+
+	// Mirrored archives are verified against tools.lock like downloaded ones.
+	t.Setenv(MirrorEnv, dir)
+	if _, _, err := Fetch(context.Background(), "tampered", "1.0.0", upstream); err == nil ||
+		!strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Fetch() of a tampered mirrored archive = %v, want checksum mismatch", err)
+	}
+}
+
+func TestSave(t *testing.T) {
+	setLock(t, Checksum{"tool", "1.0.0", "linux/amd64", digest([]byte("archive"))})
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte("archive"))
+	}))
+	t.Cleanup(server.Close)
+	dir := t.TempDir()
+
+	for range 2 {
+		path, err := Save(context.Background(), "tool", "1.0.0", "linux/amd64", server.URL+"/v1.0.0/tool.zip", dir)
+		if err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		if want := filepath.Join(dir, "tool", "1.0.0", "tool.zip"); path != want {
+			t.Errorf("Save() = %s, want %s", path, want)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Save() twice made %d requests, want 1 since the archive was already saved", requests)
+	}
+}
@@ -0,0 +1,85 @@
+// Package sgdownload downloads tool release archives for the Sage tools in this module,
+// verifying them against the SHA-256 digests in tools.lock.
+package sgdownload
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// LockFile is the path of the lock file relative to the repository root.
+const LockFile = "tools/internal/sgdownload/tools.lock"
+
+// lockHeader starts the lock file.
+const lockHeader = "# Code generated by go run ./tools/internal/lockgen. DO NOT EDIT."
+
+//go:embed tools.lock
+var lockFile []byte
+
+// Checksum is the SHA-256 digest of the release archive of a tool version for a platform.
+type Checksum struct {
+	Tool    string
+	Version string
+	// Platform is GOOS/GOARCH, e.g. "linux/amd64".
+	Platform string
+	// SHA256 is the hex-encoded digest.
+	SHA256 string
+}
+
+// ParseLock parses a lock file, with one "<tool> <version> <platform> sha256:<digest>" line per checksum.
+func ParseLock(data []byte) ([]Checksum, error) {
+	var checksums []Checksum
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		digest, ok := "", len(fields) == 4
+		if ok {
+			digest, ok = strings.CutPrefix(fields[3], "sha256:")
+		}
+		if !ok || len(digest) != 64 || strings.Trim(digest, "0123456789abcdef") != "" {
+			return nil, fmt.Errorf("tools.lock:%d: expected <tool> <version> <platform> sha256:<digest>", n)
+		}
+		checksums = append(checksums, Checksum{Tool: fields[0], Version: fields[1], Platform: fields[2], SHA256: digest})
+	}
+	return checksums, scanner.Err()
+}
+
+// FormatLock returns the lock file content for checksums, sorted by tool and platform.
+func FormatLock(checksums []Checksum) []byte {
+	checksums = slices.Clone(checksums)
+	slices.SortFunc(checksums, func(a, b Checksum) int {
+		return strings.Compare(a.Tool+" "+a.Platform, b.Tool+" "+b.Platform)
+	})
+	var b bytes.Buffer
+	b.WriteString(lockHeader + "\n")
+	for _, c := range checksums {
+		fmt.Fprintf(&b, "%s %s %s sha256:%s\n", c.Tool, c.Version, c.Platform, c.SHA256)
+	}
+	return b.Bytes()
+}
+
+// Locked returns the checksums in the embedded lock file.
+func Locked() ([]Checksum, error) {
+	return ParseLock(lockFile)
+}
+
+// lookup returns the digest of tool at version for platform in checksums. A missing digest is an
+// error, so that no release archive is installed unverified.
+func lookup(checksums []Checksum, tool, version, platform string) (string, error) {
+	for _, c := range checksums {
+		if c.Tool == tool && c.Version == version && c.Platform == platform {
+			return c.SHA256, nil
+		}
+	}
+	return "", fmt.Errorf(
+		"%s %s for %s is missing from %s, run go run ./tools/internal/lockgen", tool, version, platform, LockFile,
+	)
+}
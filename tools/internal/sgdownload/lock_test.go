@@ -0,0 +1,64 @@
+package sgdownload
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLockRoundTrip(t *testing.T) {
+	checksums := []Checksum{
+		{Tool: "stylua", Version: "2.0.1", Platform: "linux/amd64", SHA256: strings.Repeat("b", 64)},
+		{Tool: "actionlint", Version: "1.7.9", Platform: "linux/arm64", SHA256: strings.Repeat("a", 64)},
+	}
+	data := FormatLock(checksums)
+	if !strings.HasPrefix(string(data), lockHeader+"\n") {
+		t.Errorf("FormatLock() = %q, want it to start with the header", data)
+	}
+	got, err := ParseLock(data)
+	if err != nil {
+		t.Fatalf("ParseLock() failed: %v", err)
+	}
+	if len(got) != 2 || got[0] != checksums[1] || got[1] != checksums[0] {
+		t.Errorf("ParseLock(FormatLock()) = %v, want %v sorted by tool", got, checksums)
+	}
+}
+
+func TestParseLockErrors(t *testing.T) {
+	for _, line := range []string{
+		"stylua 2.0.1 linux/amd64",
+		"stylua 2.0.1 linux/amd64 md5:" + strings.Repeat("a", 64),
+		"stylua 2.0.1 linux/amd64 sha256:" + strings.Repeat("a", 63),
+		"stylua 2.0.1 linux/amd64 sha256:" + strings.Repeat("A", 64),
+	} {
+		if _, err := ParseLock([]byte("# header\n" + line + "\n")); err == nil || !strings.Contains(err.Error(), ":2:") {
+			t.Errorf("ParseLock(%q) = %v, want error on line 2", line, err)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	digest := strings.Repeat("a", 64)
+	checksums := []Checksum{{Tool: "stylua", Version: "2.0.1", Platform: "linux/amd64", SHA256: digest}}
+	for _, tt := range []struct {
+		tool, version, platform string
+		want                    string
+		wantErr                 bool
+	}{
+		{tool: "stylua", version: "2.0.1", platform: "linux/amd64", want: digest},
+		{tool: "stylua", version: "2.0.1", platform: "darwin/arm64", wantErr: true},
+		{tool: "stylua", version: "2.1.0", platform: "linux/amd64", wantErr: true},
+		{tool: "tflint", version: "0.59.1", platform: "linux/amd64", wantErr: true},
+	} {
+		got, err := lookup(checksums, tt.tool, tt.version, tt.platform)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("lookup(%s %s %s) = %q, %v, want %q, error %v",
+				tt.tool, tt.version, tt.platform, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLocked(t *testing.T) {
+	if _, err := Locked(); err != nil {
+		t.Errorf("Locked() failed on the embedded tools.lock: %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+// Package sgrelease defines the Sage tools in this module which are installed from release archives:
+// where the archive for a platform is released, its format and where the binary is inside it.
+package sgrelease
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgdownload"
+	"go.einride.tech/sage/sg"
+	"go.einride.tech/sage/sgtool"
+)
+
+// Format is the format of a release archive, also used as its file extension.
+type Format string
+
+const (
+	// Binary is a bare executable.
+	Binary Format = ""
+	// TarGz is a gzipped tarball.
+	TarGz Format = "tar.gz"
+	// Zip is a zip archive.
+	Zip Format = "zip"
+	// Gzip is a gzipped executable.
+	Gzip Format = "gz"
+)
+
+const osWindows = "windows"
+
+// Tool is a tool installed from a release archive.
+//
+// URL and BinaryPath are templates in which {name} and {version} are replaced by Name and Version,
+// {os} and {arch} by the platform's names in the release, {ext} by the archive Format and {exe} by
+// ".exe" on Windows.
+type Tool struct {
+	// Name is the name of the binary, of the tool's directory in the Sage tools cache and of the tool
+	// in tools.lock.
+	Name    string
+	Version string
+	// URL is the template of the release archive URL.
+	URL string
+	// OS and Arch map GOOS and GOARCH to their names in the release, where these differ.
+	OS   map[string]string
+	Arch map[string]string
+	// Platforms are the GOOS/GOARCH pairs the tool is released for, or empty if it's released for all.
+	Platforms []string
+	// Format is the format of the release archives.
+	Format Format
+	// WindowsFormat is the format of the Windows release archives, if it differs from Format.
+	WindowsFormat Format
+	// BinaryPath is the template of the binary's path inside TarGz and Zip archives,
+	// {name}{exe} if empty. The binary is installed as {name}{exe}, so on Windows the symlink
+	// in the Sage bin directory has the .exe extension too.
+	BinaryPath string
+}
+
+// DownloadURL returns the URL of the release archive for goos and goarch, or an error if the tool
+// isn't released for the platform.
+func (t Tool) DownloadURL(goos, goarch string) (string, error) {
+	if len(t.Platforms) > 0 && !slices.Contains(t.Platforms, goos+"/"+goarch) {
+		return "", fmt.Errorf("unsupported platform: %s/%s", goos, goarch)
+	}
+	return t.expand(t.URL, goos, goarch), nil
+}
+
+// format returns the format of the release archive for goos.
+func (t Tool) format(goos string) Format {
+	if goos == osWindows && t.WindowsFormat != "" {
+		return t.WindowsFormat
+	}
+	return t.Format
+}
+
+// binaryPath returns the path of the binary inside the release archive for goos and goarch.
+func (t Tool) binaryPath(goos, goarch string) string {
+	if t.BinaryPath == "" {
+		return t.Name + exe(goos)
+	}
+	return t.expand(t.BinaryPath, goos, goarch)
+}
+
+// expand replaces the placeholders in template for goos and goarch.
+func (t Tool) expand(template, goos, goarch string) string {
+	osName, archName := goos, goarch
+	if name, ok := t.OS[goos]; ok {
+		osName = name
+	}
+	if name, ok := t.Arch[goarch]; ok {
+		archName = name
+	}
+	return strings.NewReplacer(
+		"{name}", t.Name,
+		"{version}", t.Version,
+		"{os}", osName,
+		"{arch}", archName,
+		"{ext}", string(t.format(goos)),
+		"{exe}", exe(goos),
+	).Replace(template)
+}
+
+// exe returns the executable file extension on goos.
+func exe(goos string) string {
+	if goos == osWindows {
+		return ".exe"
+	}
+	return ""
+}
+
+// Install installs the tool for the host platform into the Sage tools cache, unless it's already
+// there, and symlinks it into the Sage bin directory. The release archive is downloaded by sgdownload.
+func (t Tool) Install(ctx context.Context) error {
+	goos, goarch := runtime.GOOS, runtime.GOARCH
+	binDir := sg.FromToolsDir(t.Name, t.Version, "bin")
+	binary := filepath.Join(binDir, t.Name+exe(goos))
+	if _, err := os.Stat(binary); err == nil {
+		_, err := sgtool.CreateSymlink(binary)
+		return err
+	}
+	url, err := t.DownloadURL(goos, goarch)
+	if err != nil {
+		return err
+	}
+	archive, cleanup, err := sgdownload.Fetch(ctx, t.Name, t.Version, url)
+	if err != nil {
+		return fmt.Errorf("unable to download %s: %w", t.Name, err)
+	}
+	defer cleanup()
+	if err := unpack(ctx, archive, t.format(goos), t.binaryPath(goos, goarch), binary); err != nil {
+		return fmt.Errorf("unable to extract %s: %w", t.Name, err)
+	}
+	_, err = sgtool.CreateSymlink(binary)
+	return err
+}
+
+// unpack writes the binary at binaryPath inside archive, in format, to binary.
+func unpack(ctx context.Context, archive string, format Format, binaryPath, binary string) error {
+	var opts []sgtool.Opt
+	switch format {
+	case Binary, Gzip:
+		return extract(archive, format, binary)
+	case TarGz:
+		opts = append(opts, sgtool.WithUntarGz())
+	case Zip:
+		opts = append(opts, sgtool.WithUnzip())
+	default:
+		return fmt.Errorf("unknown archive format %q", format)
+	}
+	destDir, binaryName := filepath.Split(binary)
+	if binaryPath != binaryName {
+		opts = append(opts, sgtool.WithRenameFile(binaryPath, binaryName))
+	}
+	return sgtool.FromLocal(ctx, archive, append(opts, sgtool.WithDestinationDir(destDir))...)
+}
+
+// extract writes the bare or gzipped executable at archive to binary.
+func extract(archive string, format Format, binary string) (err error) {
+	in, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	var r io.Reader = in
+	if format == Gzip {
+		gr, err := gzip.NewReader(in)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	if err := os.MkdirAll(filepath.Dir(binary), 0o755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(binary, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := out.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+	_, err = io.Copy(out, r)
+	return err
+}
@@ -0,0 +1,166 @@
+package sgrelease
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadURL(t *testing.T) {
+	tool := Tool{
+		Name:          "tool",
+		Version:       "1.2.3",
+		URL:           "https://example.com/v{version}/{name}-{os}-{arch}{exe}.{ext}",
+		OS:            map[string]string{"darwin": "macos"},
+		Arch:          map[string]string{"amd64": "x86_64"},
+		Platforms:     []string{"darwin/amd64", "linux/arm64", "windows/amd64"},
+		Format:        TarGz,
+		WindowsFormat: Zip,
+	}
+	for _, tt := range []struct {
+		goos, goarch string
+		want         string
+	}{
+		{"darwin", "amd64", "https://example.com/v1.2.3/tool-macos-x86_64.tar.gz"},
+		{"linux", "arm64", "https://example.com/v1.2.3/tool-linux-arm64.tar.gz"},
+		{"windows", "amd64", "https://example.com/v1.2.3/tool-windows-x86_64.exe.zip"},
+		{"linux", "amd64", ""},
+	} {
+		got, err := tool.DownloadURL(tt.goos, tt.goarch)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("DownloadURL(%s, %s) = %q, want error", tt.goos, tt.goarch, got)
+		case tt.want != "" && (err != nil || got != tt.want):
+			t.Errorf("DownloadURL(%s, %s) = %q, %v, want %q", tt.goos, tt.goarch, got, err, tt.want)
+		}
+	}
+
+	// Without Platforms, the tool is released for every platform.
+	tool.Platforms = nil
+	if _, err := tool.DownloadURL("freebsd", "386"); err != nil {
+		t.Errorf("DownloadURL(freebsd, 386) without Platforms failed: %v", err)
+	}
+}
+
+func TestBinaryPath(t *testing.T) {
+	tool := Tool{Name: "tool", Version: "1.2.3"}
+	if got := tool.binaryPath("windows", "amd64"); got != "tool.exe" {
+		t.Errorf("default binaryPath(windows) = %q, want %q", got, "tool.exe")
+	}
+	tool.BinaryPath = "{name}-{version}-{os}-{arch}/{name}{exe}"
+	if got := tool.binaryPath("linux", "arm64"); got != "tool-1.2.3-linux-arm64/tool" {
+		t.Errorf("binaryPath(linux) = %q, want %q", got, "tool-1.2.3-linux-arm64/tool")
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	content := []byte("#!/bin/sh\necho tool\n")
+	for _, tt := range []struct {
+		name    string
+		format  Format
+		archive func(t *testing.T) []byte
+	}{
+		{"binary", Binary, func(*testing.T) []byte { return content }},
+		{"gzip", Gzip, func(t *testing.T) []byte {
+			var b bytes.Buffer
+			gw := gzip.NewWriter(&b)
+			if _, err := gw.Write(content); err != nil {
+				t.Fatal(err)
+			}
+			if err := gw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			return b.Bytes()
+		}},
+		{"tar.gz", TarGz, func(t *testing.T) []byte {
+			var b bytes.Buffer
+			gw := gzip.NewWriter(&b)
+			tw := tar.NewWriter(gw)
+			for _, name := range []string{"README.md", "dist/tool"} {
+				if err := tw.WriteHeader(&tar.Header{
+					Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg,
+				}); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := tw.Write(content); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := gw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			return b.Bytes()
+		}},
+		{"zip", Zip, func(t *testing.T) []byte {
+			var b bytes.Buffer
+			zw := zip.NewWriter(&b)
+			for _, name := range []string{"README.md", "dist/tool"} {
+				w, err := zw.Create(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := w.Write(content); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := zw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			return b.Bytes()
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := filepath.Join(dir, "archive")
+			if err := os.WriteFile(archive, tt.archive(t), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			binary := filepath.Join(dir, "bin", "tool")
+			if err := unpack(context.Background(), archive, tt.format, "dist/tool", binary); err != nil {
+				t.Fatalf("unpack() failed: %v", err)
+			}
+			got, err := os.ReadFile(binary)
+			if err != nil || !bytes.Equal(got, content) {
+				t.Errorf("unpacked binary = %q, %v, want %q", got, err, content)
+			}
+		})
+	}
+}
+
+func TestUnpackBinaryAtRoot(t *testing.T) {
+	// Archives with the binary at the root, such as actionlint's and terraform's, need no renaming.
+	dir := t.TempDir()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	w, err := zw.Create("tool.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("binary")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(dir, "archive")
+	if err := os.WriteFile(archive, b.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := Tool{Name: "tool", Format: Zip}
+	binary := filepath.Join(dir, "bin", "tool"+exe("windows"))
+	binaryPath := tool.binaryPath("windows", "amd64")
+	if err := unpack(context.Background(), archive, tool.format("windows"), binaryPath, binary); err != nil {
+		t.Fatalf("unpack() failed: %v", err)
+	}
+	if got, err := os.ReadFile(binary); err != nil || string(got) != "binary" {
+		t.Errorf("unpacked binary = %q, %v, want %q", got, err, "binary")
+	}
+}
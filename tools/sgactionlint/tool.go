@@ -0,0 +1,45 @@
+// Package sgactionlint provides a Sage tool for running actionlint.
+package sgactionlint
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgrelease"
+	"go.einride.tech/sage/sg"
+)
+
+// Name is the name of actionlint, which is also its directory in the Sage tools cache.
+const Name = "actionlint"
+
+// Version is the pinned actionlint version.
+//
+// renovate: datasource=github-releases depName=rhysd/actionlint
+const Version = "1.7.9"
+
+var tool = sgrelease.Tool{
+	Name:    Name,
+	Version: Version,
+	URL: "https://github.com/rhysd/actionlint/releases/download/" +
+		"v{version}/actionlint_{version}_{os}_{arch}.{ext}",
+	Format:        sgrelease.TarGz,
+	WindowsFormat: sgrelease.Zip,
+}
+
+// Command returns an *exec.Cmd for actionlint.
+func Command(ctx context.Context, args ...string) *exec.Cmd {
+	sg.Deps(ctx, PrepareCommand)
+	return sg.Command(ctx, sg.FromBinDir(Name), args...)
+}
+
+// DownloadURL returns the URL of the actionlint release archive for goos and goarch.
+// Windows uses .zip, others use .tar.gz:
+// actionlint_1.7.9_linux_amd64.tar.gz, actionlint_1.7.9_windows_amd64.zip, etc.
+func DownloadURL(goos, goarch string) (string, error) {
+	return tool.DownloadURL(goos, goarch)
+}
+
+// PrepareCommand ensures actionlint is installed.
+func PrepareCommand(ctx context.Context) error {
+	return tool.Install(ctx)
+}
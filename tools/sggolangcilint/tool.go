@@ -3,69 +3,46 @@ package sggolangcilint
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"runtime"
 
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgrelease"
 	"go.einride.tech/sage/sg"
-	"go.einride.tech/sage/sgtool"
 )
 
-const (
-	name      = "golangci-lint"
-	osWindows = "windows"
-)
+// Name is the name of golangci-lint, which is also its directory in the Sage tools cache.
+const Name = "golangci-lint"
 
+// Version is the pinned golangci-lint version.
+//
 // renovate: datasource=github-releases depName=golangci/golangci-lint
-const version = "2.7.1"
+const Version = "2.7.1"
+
+var tool = sgrelease.Tool{
+	Name:    Name,
+	Version: Version,
+	URL: "https://github.com/golangci/golangci-lint/releases/download/" +
+		"v{version}/{name}-{version}-{os}-{arch}.{ext}",
+	Format:        sgrelease.TarGz,
+	WindowsFormat: sgrelease.Zip,
+	BinaryPath:    "{name}-{version}-{os}-{arch}/{name}{exe}",
+}
 
 // Command returns an *exec.Cmd for golangci-lint.
 func Command(ctx context.Context, args ...string) *exec.Cmd {
 	sg.Deps(ctx, PrepareCommand)
-	return sg.Command(ctx, sg.FromBinDir(name), args...)
+	return sg.Command(ctx, sg.FromBinDir(Name), args...)
+}
+
+// DownloadURL returns the URL of the golangci-lint release archive for goos and goarch.
+// Windows uses .zip, others use .tar.gz.
+func DownloadURL(goos, goarch string) (string, error) {
+	return tool.DownloadURL(goos, goarch)
 }
 
 // PrepareCommand ensures golangci-lint is installed.
 func PrepareCommand(ctx context.Context) error {
-	binDir := sg.FromToolsDir(name, version, "bin")
-	binary := filepath.Join(binDir, name)
-	hostOS := runtime.GOOS
-	hostArch := runtime.GOARCH
-
-	// Windows uses .zip, others use .tar.gz
-	var binURL string
-	if hostOS == osWindows {
-		binURL = fmt.Sprintf(
-			"https://github.com/golangci/golangci-lint/releases/download/v%s/golangci-lint-%s-%s-%s.zip",
-			version, version, hostOS, hostArch,
-		)
-	} else {
-		binURL = fmt.Sprintf(
-			"https://github.com/golangci/golangci-lint/releases/download/v%s/golangci-lint-%s-%s-%s.tar.gz",
-			version, version, hostOS, hostArch,
-		)
-	}
-
-	archiveDir := fmt.Sprintf("golangci-lint-%s-%s-%s", version, hostOS, hostArch)
-	unarchive := sgtool.WithUntarGz()
-	if hostOS == osWindows {
-		unarchive = sgtool.WithUnzip()
-	}
-
-	if err := sgtool.FromRemote(
-		ctx,
-		binURL,
-		sgtool.WithDestinationDir(binDir),
-		unarchive,
-		sgtool.WithRenameFile(fmt.Sprintf("%s/golangci-lint", archiveDir), name),
-		sgtool.WithSkipIfFileExists(binary),
-		sgtool.WithSymlink(binary),
-	); err != nil {
-		return fmt.Errorf("unable to download %s: %w", name, err)
-	}
-	return nil
+	return tool.Install(ctx)
 }
 
 // Run runs golangci-lint in the current directory.
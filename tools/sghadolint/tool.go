@@ -0,0 +1,45 @@
+// Package sghadolint provides a Sage tool for running hadolint.
+package sghadolint
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgrelease"
+	"go.einride.tech/sage/sg"
+)
+
+// Name is the name of hadolint, which is also its directory in the Sage tools cache.
+const Name = "hadolint"
+
+// Version is the pinned hadolint version.
+//
+// renovate: datasource=github-releases depName=hadolint/hadolint
+const Version = "2.14.0"
+
+var tool = sgrelease.Tool{
+	Name:      Name,
+	Version:   Version,
+	URL:       "https://github.com/hadolint/hadolint/releases/download/v{version}/hadolint-{os}-{arch}{exe}",
+	OS:        map[string]string{"darwin": "macos"},
+	Arch:      map[string]string{"amd64": "x86_64"},
+	Platforms: []string{"darwin/amd64", "darwin/arm64", "linux/amd64", "linux/arm64", "windows/amd64"},
+	Format:    sgrelease.Binary,
+}
+
+// Command returns an *exec.Cmd for hadolint.
+func Command(ctx context.Context, args ...string) *exec.Cmd {
+	sg.Deps(ctx, PrepareCommand)
+	return sg.Command(ctx, sg.FromBinDir(Name), args...)
+}
+
+// DownloadURL returns the URL of the hadolint release binary for goos and goarch:
+// hadolint-linux-x86_64, hadolint-macos-arm64, hadolint-windows-x86_64.exe, etc.
+func DownloadURL(goos, goarch string) (string, error) {
+	return tool.DownloadURL(goos, goarch)
+}
+
+// PrepareCommand ensures hadolint is installed.
+func PrepareCommand(ctx context.Context) error {
+	return tool.Install(ctx)
+}
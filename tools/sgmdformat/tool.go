@@ -0,0 +1,30 @@
+// Package sgmdformat provides a Sage tool for running mdformat.
+package sgmdformat
+
+import (
+	"context"
+	"os/exec"
+
+	"go.einride.tech/sage/tools/sguv"
+)
+
+// Name is the name of the mdformat Python package.
+const Name = "mdformat"
+
+// Version is the pinned mdformat version.
+//
+// renovate: datasource=pypi depName=mdformat
+const Version = "0.7.22"
+
+// Command returns an *exec.Cmd for mdformat.
+// mdformat is run through uv, which caches the pinned version between runs. uv installs it from its
+// package index, PyPI unless UV_DEFAULT_INDEX is set, rather than from the sage-ci tools mirror.
+func Command(ctx context.Context, args ...string) *exec.Cmd {
+	uvArgs := append([]string{"tool", "run", "--from", Name + "==" + Version, Name}, args...)
+	return sguv.Command(ctx, uvArgs...)
+}
+
+// PrepareCommand ensures uv is installed, which in turn provides mdformat.
+func PrepareCommand(ctx context.Context) error {
+	return sguv.PrepareCommand(ctx)
+}
@@ -0,0 +1,42 @@
+// Package sgopentofu provides a Sage tool for running OpenTofu.
+package sgopentofu
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgrelease"
+	"go.einride.tech/sage/sg"
+)
+
+// Name is the name of tofu, which is also its directory in the Sage tools cache.
+const Name = "tofu"
+
+// Version is the pinned tofu version.
+//
+// renovate: datasource=github-releases depName=opentofu/opentofu
+const Version = "1.10.7"
+
+var tool = sgrelease.Tool{
+	Name:    Name,
+	Version: Version,
+	URL:     "https://github.com/opentofu/opentofu/releases/download/v{version}/tofu_{version}_{os}_{arch}.{ext}",
+	Format:  sgrelease.Zip,
+}
+
+// Command returns an *exec.Cmd for OpenTofu.
+func Command(ctx context.Context, args ...string) *exec.Cmd {
+	sg.Deps(ctx, PrepareCommand)
+	return sg.Command(ctx, sg.FromBinDir(Name), args...)
+}
+
+// DownloadURL returns the URL of the OpenTofu release archive for goos and goarch:
+// tofu_1.10.7_linux_amd64.zip, tofu_1.10.7_darwin_arm64.zip, etc.
+func DownloadURL(goos, goarch string) (string, error) {
+	return tool.DownloadURL(goos, goarch)
+}
+
+// PrepareCommand ensures OpenTofu is installed.
+func PrepareCommand(ctx context.Context) error {
+	return tool.Install(ctx)
+}
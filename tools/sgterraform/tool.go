@@ -0,0 +1,42 @@
+// Package sgterraform provides a Sage tool for running terraform.
+package sgterraform
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgrelease"
+	"go.einride.tech/sage/sg"
+)
+
+// Name is the name of terraform, which is also its directory in the Sage tools cache.
+const Name = "terraform"
+
+// Version is the pinned terraform version.
+//
+// renovate: datasource=github-releases depName=hashicorp/terraform
+const Version = "1.14.0"
+
+var tool = sgrelease.Tool{
+	Name:    Name,
+	Version: Version,
+	URL:     "https://releases.hashicorp.com/terraform/{version}/terraform_{version}_{os}_{arch}.{ext}",
+	Format:  sgrelease.Zip,
+}
+
+// Command returns an *exec.Cmd for terraform.
+func Command(ctx context.Context, args ...string) *exec.Cmd {
+	sg.Deps(ctx, PrepareCommand)
+	return sg.Command(ctx, sg.FromBinDir(Name), args...)
+}
+
+// DownloadURL returns the URL of the terraform release archive for goos and goarch:
+// terraform_1.14.0_linux_amd64.zip, terraform_1.14.0_darwin_arm64.zip, etc.
+func DownloadURL(goos, goarch string) (string, error) {
+	return tool.DownloadURL(goos, goarch)
+}
+
+// PrepareCommand ensures terraform is installed.
+func PrepareCommand(ctx context.Context) error {
+	return tool.Install(ctx)
+}
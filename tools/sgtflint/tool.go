@@ -0,0 +1,42 @@
+// Package sgtflint provides a Sage tool for running tflint.
+package sgtflint
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgrelease"
+	"go.einride.tech/sage/sg"
+)
+
+// Name is the name of tflint, which is also its directory in the Sage tools cache.
+const Name = "tflint"
+
+// Version is the pinned tflint version.
+//
+// renovate: datasource=github-releases depName=terraform-linters/tflint
+const Version = "0.59.1"
+
+var tool = sgrelease.Tool{
+	Name:    Name,
+	Version: Version,
+	URL:     "https://github.com/terraform-linters/tflint/releases/download/v{version}/tflint_{os}_{arch}.{ext}",
+	Format:  sgrelease.Zip,
+}
+
+// Command returns an *exec.Cmd for tflint.
+func Command(ctx context.Context, args ...string) *exec.Cmd {
+	sg.Deps(ctx, PrepareCommand)
+	return sg.Command(ctx, sg.FromBinDir(Name), args...)
+}
+
+// DownloadURL returns the URL of the tflint release archive for goos and goarch:
+// tflint_linux_amd64.zip, tflint_darwin_arm64.zip, etc.
+func DownloadURL(goos, goarch string) (string, error) {
+	return tool.DownloadURL(goos, goarch)
+}
+
+// PrepareCommand ensures tflint is installed.
+func PrepareCommand(ctx context.Context) error {
+	return tool.Install(ctx)
+}
@@ -0,0 +1,87 @@
+// Package sgtools provides operations on all Sage tools of sage-ci installed from release archives,
+// such as vendoring the archives for installation without internet access.
+package sgtools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgdownload"
+	"github.com/fredrikaverpil/sage-ci/tools/sgactionlint"
+	"github.com/fredrikaverpil/sage-ci/tools/sggolangcilint"
+	"github.com/fredrikaverpil/sage-ci/tools/sghadolint"
+	"github.com/fredrikaverpil/sage-ci/tools/sgopentofu"
+	"github.com/fredrikaverpil/sage-ci/tools/sgstylua"
+	"github.com/fredrikaverpil/sage-ci/tools/sgterraform"
+	"github.com/fredrikaverpil/sage-ci/tools/sgtflint"
+	"github.com/fredrikaverpil/sage-ci/tools/sgtreesittercli"
+	"github.com/fredrikaverpil/sage-ci/tools/sgtsqueryls"
+	"go.einride.tech/sage/sg"
+)
+
+// MirrorEnv is the environment variable pointing tools to a mirror of their release archives,
+// either a base URL or a local directory written by Vendor.
+const MirrorEnv = sgdownload.MirrorEnv
+
+// Tool is a tool installed from a release archive.
+type Tool struct {
+	Name    string
+	Version string
+	// DownloadURL returns the URL of the release archive for GOOS and GOARCH,
+	// or an error if the tool isn't released for the platform.
+	DownloadURL func(goos, goarch string) (string, error)
+}
+
+// Platforms are the GOOS/GOARCH pairs tools are released for, though not every tool is released for all.
+var Platforms = []string{"darwin/amd64", "darwin/arm64", "linux/amd64", "linux/arm64", "windows/amd64", "windows/arm64"}
+
+// Tools returns the tools installed from release archives.
+func Tools() []Tool {
+	return []Tool{
+		{sgactionlint.Name, sgactionlint.Version, sgactionlint.DownloadURL},
+		{sggolangcilint.Name, sggolangcilint.Version, sggolangcilint.DownloadURL},
+		{sghadolint.Name, sghadolint.Version, sghadolint.DownloadURL},
+		{sgopentofu.Name, sgopentofu.Version, sgopentofu.DownloadURL},
+		{sgstylua.Name, sgstylua.Version, sgstylua.DownloadURL},
+		{sgterraform.Name, sgterraform.Version, sgterraform.DownloadURL},
+		{sgtflint.Name, sgtflint.Version, sgtflint.DownloadURL},
+		{sgtreesittercli.Name, sgtreesittercli.Version, sgtreesittercli.DownloadURL},
+		{sgtsqueryls.Name, sgtsqueryls.Version, sgtsqueryls.DownloadURL},
+	}
+}
+
+// Vendor downloads the release archives of all tools for goos and goarch from their release URLs into dir,
+// verified against the tool lock file. Setting MirrorEnv to dir, or to a URL serving its content, makes
+// the tools install from there. Vendor returns the paths of the archives.
+func Vendor(ctx context.Context, dir, goos, goarch string) ([]string, error) {
+	return vendor(ctx, Tools(), dir, goos, goarch, sgdownload.Save)
+}
+
+// saveFunc saves a release archive into a mirror directory, see sgdownload.Save.
+type saveFunc func(ctx context.Context, tool, version, platform, url, dir string) (string, error)
+
+func vendor(ctx context.Context, tools []Tool, dir, goos, goarch string, save saveFunc) ([]string, error) {
+	var archives []string
+	for _, tool := range tools {
+		url, err := tool.DownloadURL(goos, goarch)
+		if err != nil {
+			sg.Logger(ctx).Printf("skipping %s: %v", tool.Name, err)
+			continue
+		}
+		archive, err := save(ctx, tool.Name, tool.Version, goos+"/"+goarch, url, dir)
+		if errors.Is(err, sgdownload.ErrNotFound) {
+			sg.Logger(ctx).Printf("skipping %s: no release for %s/%s", tool.Name, goos, goarch)
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("vendor %s: %w", tool.Name, err)
+		}
+		archives = append(archives, archive)
+	}
+	if len(archives) == 0 {
+		return nil, fmt.Errorf("no tools are released for %s/%s, expected one of %s",
+			goos, goarch, strings.Join(Platforms, ", "))
+	}
+	return archives, nil
+}
@@ -0,0 +1,145 @@
+package sgtools
+
+import (
+	"context"
+	"errors"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgdownload"
+)
+
+func TestDownloadURLs(t *testing.T) {
+	// The release archive names per tool and platform, with "" for platforms the tool isn't released for.
+	archives := map[string]map[string]string{
+		"actionlint": {
+			"darwin/amd64":  "actionlint_{version}_darwin_amd64.tar.gz",
+			"darwin/arm64":  "actionlint_{version}_darwin_arm64.tar.gz",
+			"linux/amd64":   "actionlint_{version}_linux_amd64.tar.gz",
+			"linux/arm64":   "actionlint_{version}_linux_arm64.tar.gz",
+			"windows/amd64": "actionlint_{version}_windows_amd64.zip",
+			"windows/arm64": "actionlint_{version}_windows_arm64.zip",
+		},
+		"golangci-lint": {
+			"darwin/amd64":  "golangci-lint-{version}-darwin-amd64.tar.gz",
+			"darwin/arm64":  "golangci-lint-{version}-darwin-arm64.tar.gz",
+			"linux/amd64":   "golangci-lint-{version}-linux-amd64.tar.gz",
+			"linux/arm64":   "golangci-lint-{version}-linux-arm64.tar.gz",
+			"windows/amd64": "golangci-lint-{version}-windows-amd64.zip",
+			"windows/arm64": "golangci-lint-{version}-windows-arm64.zip",
+		},
+		"hadolint": {
+			"darwin/amd64":  "hadolint-macos-x86_64",
+			"darwin/arm64":  "hadolint-macos-arm64",
+			"linux/amd64":   "hadolint-linux-x86_64",
+			"linux/arm64":   "hadolint-linux-arm64",
+			"windows/amd64": "hadolint-windows-x86_64.exe",
+			"windows/arm64": "",
+		},
+		"tofu": {
+			"darwin/amd64":  "tofu_{version}_darwin_amd64.zip",
+			"darwin/arm64":  "tofu_{version}_darwin_arm64.zip",
+			"linux/amd64":   "tofu_{version}_linux_amd64.zip",
+			"linux/arm64":   "tofu_{version}_linux_arm64.zip",
+			"windows/amd64": "tofu_{version}_windows_amd64.zip",
+			"windows/arm64": "tofu_{version}_windows_arm64.zip",
+		},
+		"stylua": {
+			"darwin/amd64":  "stylua-macos-x86_64.zip",
+			"darwin/arm64":  "stylua-macos-aarch64.zip",
+			"linux/amd64":   "stylua-linux-x86_64.zip",
+			"linux/arm64":   "stylua-linux-aarch64.zip",
+			"windows/amd64": "stylua-windows-x86_64.zip",
+			"windows/arm64": "stylua-windows-aarch64.zip",
+		},
+		"terraform": {
+			"darwin/amd64":  "terraform_{version}_darwin_amd64.zip",
+			"darwin/arm64":  "terraform_{version}_darwin_arm64.zip",
+			"linux/amd64":   "terraform_{version}_linux_amd64.zip",
+			"linux/arm64":   "terraform_{version}_linux_arm64.zip",
+			"windows/amd64": "terraform_{version}_windows_amd64.zip",
+			"windows/arm64": "terraform_{version}_windows_arm64.zip",
+		},
+		"tflint": {
+			"darwin/amd64":  "tflint_darwin_amd64.zip",
+			"darwin/arm64":  "tflint_darwin_arm64.zip",
+			"linux/amd64":   "tflint_linux_amd64.zip",
+			"linux/arm64":   "tflint_linux_arm64.zip",
+			"windows/amd64": "tflint_windows_amd64.zip",
+			"windows/arm64": "tflint_windows_arm64.zip",
+		},
+		"tree-sitter": {
+			"darwin/amd64":  "tree-sitter-macos-x64.gz",
+			"darwin/arm64":  "tree-sitter-macos-arm64.gz",
+			"linux/amd64":   "tree-sitter-linux-x64.gz",
+			"linux/arm64":   "tree-sitter-linux-arm64.gz",
+			"windows/amd64": "tree-sitter-windows-x64.gz",
+			"windows/arm64": "tree-sitter-windows-arm64.gz",
+		},
+		"ts_query_ls": {
+			"darwin/amd64":  "ts_query_ls-x86_64-apple-darwin.tar.gz",
+			"darwin/arm64":  "ts_query_ls-aarch64-apple-darwin.tar.gz",
+			"linux/amd64":   "ts_query_ls-x86_64-unknown-linux-gnu.tar.gz",
+			"linux/arm64":   "ts_query_ls-aarch64-unknown-linux-gnu.tar.gz",
+			"windows/amd64": "ts_query_ls-x86_64-pc-windows-msvc.zip",
+			"windows/arm64": "",
+		},
+	}
+	for _, tool := range Tools() {
+		t.Run(tool.Name, func(t *testing.T) {
+			names, ok := archives[tool.Name]
+			if !ok {
+				t.Fatalf("%s is missing from the test cases", tool.Name)
+			}
+			for _, platform := range Platforms {
+				goos, goarch, _ := strings.Cut(platform, "/")
+				url, err := tool.DownloadURL(goos, goarch)
+				want := strings.ReplaceAll(names[platform], "{version}", tool.Version)
+				switch {
+				case want == "" && err == nil:
+					t.Errorf("DownloadURL(%s) = %q, want error", platform, url)
+				case want == "":
+				case err != nil:
+					t.Errorf("DownloadURL(%s) failed: %v", platform, err)
+				case path.Base(url) != want || !strings.HasPrefix(url, "https://") || !strings.Contains(url, tool.Version):
+					t.Errorf("DownloadURL(%s) = %q, want an https URL with the version, ending in %s", platform, url, want)
+				}
+			}
+		})
+	}
+}
+
+func TestVendor(t *testing.T) {
+	tools := []Tool{
+		{Name: "fake", Version: "1.0.0", DownloadURL: func(goos, goarch string) (string, error) {
+			return "https://example.com/releases/v1.0.0/fake_" + goos + "_" + goarch + ".tar.gz", nil
+		}},
+		{Name: "linux-only", Version: "2.0.0", DownloadURL: func(goos, goarch string) (string, error) {
+			return "", errors.New("unsupported platform")
+		}},
+	}
+	// Archives are verified by sgdownload.Save against tools.lock, which has no fake tools.
+	save := func(_ context.Context, tool, version, platform, url, dir string) (string, error) {
+		if platform != "linux/amd64" {
+			return "", sgdownload.ErrNotFound
+		}
+		return filepath.Join(dir, tool, version, path.Base(url)), nil
+	}
+	dir := t.TempDir()
+
+	archives, err := vendor(context.Background(), tools, dir, "linux", "amd64", save)
+	if err != nil {
+		t.Fatalf("vendor() failed: %v", err)
+	}
+	want := filepath.Join(dir, "fake", "1.0.0", "fake_linux_amd64.tar.gz")
+	if len(archives) != 1 || archives[0] != want {
+		t.Errorf("vendor() = %v, want [%s]", archives, want)
+	}
+
+	// The fake tool isn't released for darwin/arm64, so nothing can be vendored.
+	if _, err := vendor(context.Background(), tools, dir, "darwin", "arm64", save); err == nil {
+		t.Error("vendor() for a platform without releases succeeded, want error")
+	}
+}
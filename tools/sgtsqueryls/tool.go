@@ -3,91 +3,45 @@ package sgtsqueryls
 
 import (
 	"context"
-	"fmt"
 	"os/exec"
-	"path/filepath"
-	"runtime"
 
+	"github.com/fredrikaverpil/sage-ci/tools/internal/sgrelease"
 	"go.einride.tech/sage/sg"
-	"go.einride.tech/sage/sgtool"
 )
 
-const name = "ts_query_ls"
+// Name is the name of ts_query_ls, which is also its directory in the Sage tools cache.
+const Name = "ts_query_ls"
 
+// Version is the pinned ts_query_ls version.
+//
 // renovate: datasource=github-releases depName=ribru17/ts_query_ls
-const version = "3.15.1"
-
-func tsQueryLsPlatform(hostOS, hostArch string) (string, error) {
-	var target string
-	switch {
-	case hostOS == "darwin" && hostArch == "arm64":
-		target = "aarch64-apple-darwin"
-	case hostOS == "darwin" && hostArch == "amd64":
-		target = "x86_64-apple-darwin"
-	case hostOS == "linux" && hostArch == "arm64":
-		target = "aarch64-unknown-linux-gnu"
-	case hostOS == "linux" && hostArch == "amd64":
-		target = "x86_64-unknown-linux-gnu"
-	case hostOS == "windows" && hostArch == "amd64":
-		target = "x86_64-pc-windows-msvc"
-	default:
-		return "", fmt.Errorf("unsupported platform: %s/%s", hostOS, hostArch)
-	}
-	return target, nil
+const Version = "3.15.1"
+
+// The release archives are named by Rust target triple, such as aarch64-apple-darwin.
+var tool = sgrelease.Tool{
+	Name:          Name,
+	Version:       Version,
+	URL:           "https://github.com/ribru17/ts_query_ls/releases/download/v{version}/ts_query_ls-{arch}-{os}.{ext}",
+	OS:            map[string]string{"darwin": "apple-darwin", "linux": "unknown-linux-gnu", "windows": "pc-windows-msvc"},
+	Arch:          map[string]string{"amd64": "x86_64", "arm64": "aarch64"},
+	Platforms:     []string{"darwin/amd64", "darwin/arm64", "linux/amd64", "linux/arm64", "windows/amd64"},
+	Format:        sgrelease.TarGz,
+	WindowsFormat: sgrelease.Zip,
 }
 
 // Command returns an *exec.Cmd for ts_query_ls.
 func Command(ctx context.Context, args ...string) *exec.Cmd {
 	sg.Deps(ctx, PrepareCommand)
-	return sg.Command(ctx, sg.FromBinDir(name), args...)
+	return sg.Command(ctx, sg.FromBinDir(Name), args...)
+}
+
+// DownloadURL returns the URL of the ts_query_ls release archive for goos and goarch:
+// ts_query_ls-aarch64-apple-darwin.tar.gz, ts_query_ls-x86_64-pc-windows-msvc.zip, etc.
+func DownloadURL(goos, goarch string) (string, error) {
+	return tool.DownloadURL(goos, goarch)
 }
 
 // PrepareCommand ensures ts_query_ls is installed.
 func PrepareCommand(ctx context.Context) error {
-	binDir := sg.FromToolsDir(name, version, "bin")
-	binary := filepath.Join(binDir, name)
-	hostOS := runtime.GOOS
-	hostArch := runtime.GOARCH
-
-	target, err := tsQueryLsPlatform(hostOS, hostArch)
-	if err != nil {
-		return err
-	}
-
-	// ts_query_ls-aarch64-apple-darwin.tar.gz, ts_query_ls-x86_64-pc-windows-msvc.zip
-	var binURL string
-	if hostOS == "windows" {
-		binURL = fmt.Sprintf(
-			"https://github.com/ribru17/ts_query_ls/releases/download/v%s/ts_query_ls-%s.zip",
-			version, target,
-		)
-	} else {
-		binURL = fmt.Sprintf(
-			"https://github.com/ribru17/ts_query_ls/releases/download/v%s/ts_query_ls-%s.tar.gz",
-			version, target,
-		)
-	}
-
-	binaryName := name
-	if hostOS == "windows" {
-		binaryName = name + ".exe"
-	}
-
-	unarchive := sgtool.WithUntarGz()
-	if hostOS == "windows" {
-		unarchive = sgtool.WithUnzip()
-	}
-
-	if err := sgtool.FromRemote(
-		ctx,
-		binURL,
-		sgtool.WithDestinationDir(binDir),
-		unarchive,
-		sgtool.WithRenameFile(binaryName, binaryName),
-		sgtool.WithSkipIfFileExists(binary),
-		sgtool.WithSymlink(binary),
-	); err != nil {
-		return fmt.Errorf("unable to download %s: %w", name, err)
-	}
-	return nil
+	return tool.Install(ctx)
 }
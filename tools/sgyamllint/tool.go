@@ -0,0 +1,47 @@
+// Package sgyamllint provides a Sage tool for running yamllint.
+package sgyamllint
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"os/exec"
+
+	"go.einride.tech/sage/sg"
+	"go.einride.tech/sage/tools/sguv"
+)
+
+// Name is the name of the yamllint Python package.
+const Name = "yamllint"
+
+// Version is the pinned yamllint version.
+//
+// renovate: datasource=pypi depName=yamllint
+const Version = "1.37.1"
+
+//go:embed yamllint.yml
+var defaultConfig string
+
+// Command returns an *exec.Cmd for yamllint.
+// yamllint is run through uv, which caches the pinned version between runs. uv installs it from its
+// package index, PyPI unless UV_DEFAULT_INDEX is set, rather than from the sage-ci tools mirror.
+func Command(ctx context.Context, args ...string) *exec.Cmd {
+	uvArgs := append([]string{"tool", "run", "--from", Name + "==" + Version, Name}, args...)
+	return sguv.Command(ctx, uvArgs...)
+}
+
+// PrepareCommand ensures uv is installed, which in turn provides yamllint.
+func PrepareCommand(ctx context.Context) error {
+	return sguv.PrepareCommand(ctx)
+}
+
+// ConfigArgs returns the yamllint arguments selecting a configuration.
+// A project-level yamllint config is preferred; otherwise the bundled default is used.
+func ConfigArgs() []string {
+	for _, file := range []string{".yamllint", ".yamllint.yml", ".yamllint.yaml"} {
+		if _, err := os.Stat(sg.FromGitRoot(file)); err == nil {
+			return []string{"-c", sg.FromGitRoot(file)}
+		}
+	}
+	return []string{"-d", defaultConfig}
+}
@@ -2,7 +2,12 @@
 package github
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/fredrikaverpil/sage-ci/config"
 )
@@ -12,13 +17,122 @@ const defaultOutputDir = ".github/workflows"
 // outputDir can be overridden in tests.
 var outputDir = defaultOutputDir
 
+// generatedHeader starts the first line of every generated workflow.
+const generatedHeader = "# Generated by sage-ci"
+
+// ejectedHeader replaces the first line of ejected workflows.
+const ejectedHeader = "# Ejected from sage-ci, maintained in this repository."
+
+// IsGenerated reports whether content is a workflow generated by sage-ci,
+// as opposed to an ejected or hand-written workflow.
+func IsGenerated(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(generatedHeader))
+}
+
 // Sync generates GitHub Actions workflows based on the provided configuration.
 func Sync(cfg config.Config) error {
 	cfg = cfg.WithDefaults()
 
-	if err := render(cfg); err != nil {
+	if err := render(cfg, outputDir); err != nil {
 		return fmt.Errorf("render github workflows: %w", err)
 	}
 
 	return nil
 }
+
+// StaleWorkflows returns the workflow files in the output directory which don't match the workflows
+// generated for cfg: changed or missing workflows, and sage-ci workflows which are no longer generated.
+func StaleWorkflows(cfg config.Config) ([]string, error) {
+	cfg = cfg.WithDefaults()
+
+	tmpDir, err := os.MkdirTemp("", "sage-ci-workflows-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	if err := render(cfg, tmpDir); err != nil {
+		return nil, fmt.Errorf("render github workflows: %w", err)
+	}
+
+	generated, err := filepath.Glob(filepath.Join(tmpDir, "sage-ci-*.yml"))
+	if err != nil {
+		return nil, fmt.Errorf("list generated workflows: %w", err)
+	}
+	existing, err := filepath.Glob(filepath.Join(outputDir, "sage-ci-*.yml"))
+	if err != nil {
+		return nil, fmt.Errorf("list workflows: %w", err)
+	}
+	var stale []string
+	for _, path := range generated {
+		name := filepath.Base(path)
+		want, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read generated workflow: %w", err)
+		}
+		got, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil || !bytes.Equal(got, want) {
+			stale = append(stale, name)
+		}
+	}
+	for _, path := range existing {
+		name := filepath.Base(path)
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			continue
+		}
+		// Ejected workflows are no longer generated, but aren't stale.
+		if content, err := os.ReadFile(path); err == nil && IsGenerated(content) {
+			stale = append(stale, name)
+		}
+	}
+	slices.Sort(stale)
+	return stale, nil
+}
+
+// Eject writes the workflows generated for cfg with the given names, or all generated workflows if names
+// is empty, with the "DO NOT EDIT" header replaced so they can be maintained by hand. It returns the names
+// of the ejected workflows, which must be added to SkipWorkflows so Sync stops overwriting them.
+func Eject(cfg config.Config, names []string) ([]string, error) {
+	cfg = cfg.WithDefaults()
+
+	tmpDir, err := os.MkdirTemp("", "sage-ci-workflows-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	if err := render(cfg, tmpDir); err != nil {
+		return nil, fmt.Errorf("render github workflows: %w", err)
+	}
+
+	generated, err := filepath.Glob(filepath.Join(tmpDir, "sage-ci-*.yml"))
+	if err != nil {
+		return nil, fmt.Errorf("list generated workflows: %w", err)
+	}
+	var generatedNames []string
+	for _, path := range generated {
+		generatedNames = append(generatedNames, strings.TrimSuffix(filepath.Base(path), ".yml"))
+	}
+	if len(names) == 0 {
+		names = generatedNames
+	}
+	for _, name := range names {
+		if !slices.Contains(generatedNames, name) {
+			return nil, fmt.Errorf("workflow %s is not generated for this config", name)
+		}
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create output dir: %w", err)
+	}
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Join(tmpDir, name+".yml"))
+		if err != nil {
+			return nil, fmt.Errorf("read generated workflow: %w", err)
+		}
+		_, rest, _ := bytes.Cut(content, []byte("\n"))
+		content = append([]byte(ejectedHeader+"\n"), rest...)
+		if err := os.WriteFile(filepath.Join(outputDir, name+".yml"), content, 0o644); err != nil {
+			return nil, fmt.Errorf("write workflow %s: %w", name, err)
+		}
+	}
+	return names, nil
+}
@@ -3,6 +3,8 @@ package github
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/fredrikaverpil/sage-ci/config"
@@ -81,9 +83,529 @@ func TestSync(t *testing.T) {
 	if _, err := os.Stat(filepath.Join(tmpDir3, "sage-ci-go-ci.yml")); !os.IsNotExist(err) {
 		t.Error("sage-ci-go-ci.yml should not exist when GoModules is empty")
 	}
+	if _, err := os.Stat(filepath.Join(tmpDir3, "sage-ci-terraform-ci.yml")); !os.IsNotExist(err) {
+		t.Error("sage-ci-terraform-ci.yml should not exist when TerraformModules is empty")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir3, "sage-ci-docs-ci.yml")); !os.IsNotExist(err) {
+		t.Error("sage-ci-docs-ci.yml should not exist when no docs files are configured")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir3, "sage-ci-lint.yml")); !os.IsNotExist(err) {
+		t.Error("sage-ci-lint.yml should not exist when nothing is configured for linting")
+	}
 
 	// Generic workflows should still exist
 	if _, err := os.Stat(filepath.Join(tmpDir3, "sage-ci-pr.yml")); os.IsNotExist(err) {
 		t.Error("sage-ci-pr.yml should exist even without modules")
 	}
 }
+
+func TestSyncDocs(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sage-ci-test-docs-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+	origOutputDir := outputDir
+	outputDir = tmpDir
+	t.Cleanup(func() { outputDir = origOutputDir })
+
+	cfg := config.Config{
+		MarkdownFiles: []string{"*.md"},
+	}
+
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "sage-ci-docs-ci.yml"))
+	if err != nil {
+		t.Fatalf("expected sage-ci-docs-ci.yml to exist: %v", err)
+	}
+	if !strings.Contains(string(content), "make markdown-format") {
+		t.Error("sage-ci-docs-ci.yml should contain the markdown-format job")
+	}
+	if strings.Contains(string(content), "make yaml-lint") {
+		t.Error("sage-ci-docs-ci.yml should not contain the yaml-lint job without YAMLFiles")
+	}
+
+	// A docs workflow without jobs is invalid, so it is not generated when all docs targets are skipped.
+	outputDir = t.TempDir()
+	cfg.SkipTargets = config.SkipTargets{"MarkdownFormat": {"*"}}
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "sage-ci-docs-ci.yml")); !os.IsNotExist(err) {
+		t.Error("sage-ci-docs-ci.yml should not exist when all docs targets are skipped")
+	}
+}
+
+func TestSyncLint(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sage-ci-test-lint-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+	origOutputDir := outputDir
+	outputDir = tmpDir
+	t.Cleanup(func() { outputDir = origOutputDir })
+
+	cfg := config.Config{
+		ActionsWorkflows: []string{".github/workflows/*.yml"},
+	}
+
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "sage-ci-lint.yml"))
+	if err != nil {
+		t.Fatalf("expected sage-ci-lint.yml to exist: %v", err)
+	}
+	if !strings.Contains(string(content), "make actions-lint") {
+		t.Error("sage-ci-lint.yml should contain the actionlint job")
+	}
+	if strings.Contains(string(content), "make docker-lint") {
+		t.Error("sage-ci-lint.yml should not contain the hadolint job without Dockerfiles")
+	}
+
+	// A lint workflow without jobs is invalid, so it is not generated when all lint targets are skipped.
+	outputDir = t.TempDir()
+	cfg.SkipTargets = config.SkipTargets{"ActionsLint": {"*"}}
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "sage-ci-lint.yml")); !os.IsNotExist(err) {
+		t.Error("sage-ci-lint.yml should not exist when all lint targets are skipped")
+	}
+}
+
+func TestSyncReleaseGoBinaries(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sage-ci-test-release-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+	origOutputDir := outputDir
+	outputDir = tmpDir
+	t.Cleanup(func() { outputDir = origOutputDir })
+
+	cfg := config.Config{
+		GoModules:  []string{"."},
+		GoBinaries: []string{"cmd/tool"},
+	}
+
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "sage-ci-release.yml"))
+	if err != nil {
+		t.Fatalf("expected sage-ci-release.yml to exist: %v", err)
+	}
+	if !strings.Contains(string(content), "make go-cross-build") {
+		t.Error("sage-ci-release.yml should contain the go-binaries job")
+	}
+	if !strings.Contains(string(content), "dist/* --clobber") {
+		t.Error("sage-ci-release.yml should upload from the default dist directory")
+	}
+}
+
+func TestSyncPythonTypecheckers(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sage-ci-test-python-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+	origOutputDir := outputDir
+	outputDir = tmpDir
+	t.Cleanup(func() { outputDir = origOutputDir })
+
+	cfg := config.Config{
+		PythonModules: []string{"app", "lib"},
+		PythonOptions: map[string]config.PythonOptions{
+			"*":   {Typechecker: config.TypecheckerTy},
+			"lib": {Typechecker: config.TypecheckerPyright},
+		},
+		SkipTargets: config.SkipTargets{"PythonPyright": {"lib"}},
+	}
+
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "sage-ci-python-ci.yml"))
+	if err != nil {
+		t.Fatalf("expected sage-ci-python-ci.yml to exist: %v", err)
+	}
+	if !strings.Contains(string(content), "make python-ty") {
+		t.Error("sage-ci-python-ci.yml should run ty")
+	}
+	for _, target := range []string{"make python-mypy", "make python-pyright", "make python-build"} {
+		if strings.Contains(string(content), target) {
+			t.Errorf("sage-ci-python-ci.yml should not contain %q", target)
+		}
+	}
+	if !strings.Contains(string(content), "make python-lock-check") {
+		t.Error("sage-ci-python-ci.yml should contain the lock check job")
+	}
+
+	// Verify packaging jobs for distributable packages
+	cfg.PythonOptions["lib"] = config.PythonOptions{Package: true}
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync with package failed: %v", err)
+	}
+	content, err = os.ReadFile(filepath.Join(tmpDir, "sage-ci-python-ci.yml"))
+	if err != nil {
+		t.Fatalf("expected sage-ci-python-ci.yml to exist: %v", err)
+	}
+	for _, target := range []string{"make python-build", "make python-smoke"} {
+		if !strings.Contains(string(content), target) {
+			t.Errorf("sage-ci-python-ci.yml should contain %q", target)
+		}
+	}
+}
+
+func TestSyncModuleMatrix(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sage-ci-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+	origOutputDir := outputDir
+	outputDir = tmpDir
+	t.Cleanup(func() { outputDir = origOutputDir })
+
+	cfg := config.Config{
+		GoModules:     []string{".", "tools"},
+		PythonModules: []string{"py"},
+		ModuleOptions: map[string]config.ModuleOptions{
+			"tools": {OSVersions: []string{"ubuntu-latest", "windows-latest"}},
+		},
+	}.WithDefaults()
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	goContent, err := os.ReadFile(filepath.Join(tmpDir, "sage-ci-go-ci.yml"))
+	if err != nil {
+		t.Fatalf("expected sage-ci-go-ci.yml to exist: %v", err)
+	}
+	for _, want := range []string{
+		`{"module":".","os":"ubuntu-latest","go":"stable"}`,
+		`{"module":"tools","os":"windows-latest","go":"stable"}`,
+		"SAGE_CI_MODULE: ${{ matrix.module }}",
+	} {
+		if !strings.Contains(string(goContent), want) {
+			t.Errorf("sage-ci-go-ci.yml should contain %q", want)
+		}
+	}
+
+	// Modules without overrides keep the repo-wide matrix
+	pythonContent, err := os.ReadFile(filepath.Join(tmpDir, "sage-ci-python-ci.yml"))
+	if err != nil {
+		t.Fatalf("expected sage-ci-python-ci.yml to exist: %v", err)
+	}
+	if strings.Contains(string(pythonContent), "SAGE_CI_MODULE") {
+		t.Error("sage-ci-python-ci.yml should not use a per-module matrix")
+	}
+}
+
+func TestSyncSkipPatterns(t *testing.T) {
+	tmpDir := t.TempDir()
+	origOutputDir := outputDir
+	outputDir = tmpDir
+	t.Cleanup(func() { outputDir = origOutputDir })
+
+	cfg := config.Config{
+		GoModules:     []string{".", "examples/a"},
+		PythonModules: []string{"examples/py"},
+		SkipWorkflows: []string{"sage-ci-python-*", "re:sage-ci-(stale|release)"},
+		SkipTargets:   config.SkipTargets{"Go*": {"examples/**"}, "re:Go(Lint|Format)": {"."}},
+	}.WithDefaults()
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	for _, name := range []string{"sage-ci-python-ci.yml", "sage-ci-stale.yml", "sage-ci-release.yml"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be skipped", name)
+		}
+	}
+	content, err := os.ReadFile(filepath.Join(tmpDir, "sage-ci-go-ci.yml"))
+	if err != nil {
+		t.Fatalf("expected sage-ci-go-ci.yml to exist: %v", err)
+	}
+	for _, target := range []string{"make go-lint", "make go-format"} {
+		if strings.Contains(string(content), target) {
+			t.Errorf("sage-ci-go-ci.yml should not contain %q", target)
+		}
+	}
+	if !strings.Contains(string(content), "make go-test") {
+		t.Error("sage-ci-go-ci.yml should contain make go-test")
+	}
+}
+
+func TestSyncTriggers(t *testing.T) {
+	tmpDir := t.TempDir()
+	origOutputDir := outputDir
+	outputDir = tmpDir
+	t.Cleanup(func() { outputDir = origOutputDir })
+
+	cfg := config.Config{
+		GoModules:        []string{"."},
+		DefaultBranch:    "master",
+		PushBranches:     []string{"release/*", "master"},
+		PushTags:         []string{"v*"},
+		WorkflowDispatch: true,
+		MergeGroup:       true,
+		StaleCron:        "30 1 * * 1",
+		SyncCron:         "0 6 * * 1",
+	}.WithDefaults()
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	for name, wants := range map[string][]string{
+		"sage-ci-go-ci.yml": {
+			`branches: ["master","release/*"]`,
+			`tags: ["v*"]`,
+			"  merge_group:\n",
+			"  workflow_dispatch:\n",
+		},
+		"sage-ci-release.yml": {`- "master"`},
+		"sage-ci-stale.yml":   {`cron: "30 1 * * 1"`},
+		"sage-ci-sync.yml":    {`cron: "0 6 * * 1"`},
+	} {
+		content, err := os.ReadFile(filepath.Join(tmpDir, name))
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+		for _, want := range wants {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("%s should contain %q", name, want)
+			}
+		}
+	}
+
+	// Without options, CI only runs on pushes to the default branch and pull requests.
+	outputDir = t.TempDir()
+	if err := Sync(config.Config{GoModules: []string{"."}}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "sage-ci-go-ci.yml"))
+	if err != nil {
+		t.Fatalf("expected sage-ci-go-ci.yml to exist: %v", err)
+	}
+	want := "on:\n  push:\n    branches: [\"main\"]\n  pull_request:\n\njobs:"
+	if !strings.Contains(string(content), want) {
+		t.Errorf("sage-ci-go-ci.yml should contain %q", want)
+	}
+
+	// Without a default branch, releases are cut from main or master.
+	content, err = os.ReadFile(filepath.Join(outputDir, "sage-ci-release.yml"))
+	if err != nil {
+		t.Fatalf("expected sage-ci-release.yml to exist: %v", err)
+	}
+	if want := "    branches:\n      - \"main\"\n      - \"master\"\n"; !strings.Contains(string(content), want) {
+		t.Errorf("sage-ci-release.yml should contain %q", want)
+	}
+}
+
+func TestSyncPolicies(t *testing.T) {
+	tmpDir := t.TempDir()
+	origOutputDir := outputDir
+	outputDir = tmpDir
+	t.Cleanup(func() { outputDir = origOutputDir })
+
+	cfg := config.Config{
+		Stale: config.StaleConfig{DaysBeforeStale: config.Days(60), ExemptLabels: []string{"security"}},
+		PRTitle: config.PRTitleConfig{
+			Types:          []string{"feat", "fix"},
+			RequireScope:   true,
+			SubjectPattern: `^[a-z].*\.$`,
+		},
+	}.WithDefaults()
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	for name, wants := range map[string][]string{
+		"sage-ci-stale.yml": {
+			"open for **60 days** with no activity",
+			"closed in another **30 days**",
+			"days-before-stale: 60\n",
+			"days-before-close: 30\n",
+			"exempt-pr-labels: security\n",
+		},
+		"sage-ci-pr.yml": {
+			"requireScope: true\n",
+			`subjectPattern: "^[a-z].*\\.$"`,
+			"types: |\n            feat\n            fix\n          ignoreLabels: |\n            autorelease: pending\n",
+		},
+	} {
+		content, err := os.ReadFile(filepath.Join(tmpDir, name))
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+		for _, want := range wants {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("%s should contain %q", name, want)
+			}
+		}
+	}
+
+	// With days-before-close -1, stale issues and pull requests are never closed.
+	outputDir = t.TempDir()
+	cfg = config.Config{Stale: config.StaleConfig{DaysBeforeClose: config.Days(-1)}}.WithDefaults()
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "sage-ci-stale.yml"))
+	if err != nil {
+		t.Fatalf("expected sage-ci-stale.yml to exist: %v", err)
+	}
+	if !strings.Contains(string(content), "days-before-close: -1\n") ||
+		strings.Contains(string(content), "closed in another") {
+		t.Errorf("sage-ci-stale.yml should never close stale issues:\n%s", content)
+	}
+}
+
+func TestSyncRunner(t *testing.T) {
+	tmpDir := t.TempDir()
+	origOutputDir := outputDir
+	outputDir = tmpDir
+	t.Cleanup(func() { outputDir = origOutputDir })
+
+	cfg := config.Config{
+		GoModules:     []string{"."},
+		PythonModules: []string{"py"},
+		Runner:        config.RunnerSageCi,
+	}.WithDefaults()
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	for name, want := range map[string]string{
+		"sage-ci-go-ci.yml":     "run: go -C .sage run github.com/fredrikaverpil/sage-ci/cmd/sage-ci run go-test\n",
+		"sage-ci-python-ci.yml": "run: go -C .sage run github.com/fredrikaverpil/sage-ci/cmd/sage-ci run python-mypy\n",
+		"sage-ci-sync.yml":      "run: go -C .sage run github.com/fredrikaverpil/sage-ci/cmd/sage-ci run update-sage-ci\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(tmpDir, name))
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+		if !strings.Contains(string(content), want) {
+			t.Errorf("%s should contain %q", name, want)
+		}
+		if !strings.Contains(string(content), "GOWORK: \"off\"") {
+			t.Errorf("%s should set GOWORK", name)
+		}
+	}
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(tmpDir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(content), "run: make ") {
+			t.Errorf("%s should not run make", entry.Name())
+		}
+	}
+}
+
+func TestStaleWorkflows(t *testing.T) {
+	tmpDir := t.TempDir()
+	origOutputDir := outputDir
+	outputDir = tmpDir
+	t.Cleanup(func() { outputDir = origOutputDir })
+
+	cfg := config.Config{GoModules: []string{"."}}
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	stale, err := StaleWorkflows(cfg)
+	if err != nil {
+		t.Fatalf("StaleWorkflows() failed: %v", err)
+	}
+	if len(stale) > 0 {
+		t.Errorf("StaleWorkflows() = %v after Sync, want none", stale)
+	}
+
+	// Changed, missing and no longer generated workflows are stale.
+	if err := os.WriteFile(filepath.Join(tmpDir, "sage-ci-go-ci.yml"), []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(tmpDir, "sage-ci-pr.yml")); err != nil {
+		t.Fatal(err)
+	}
+	cfg.SkipWorkflows = []string{"sage-ci-stale"}
+	stale, err = StaleWorkflows(cfg)
+	if err != nil {
+		t.Fatalf("StaleWorkflows() failed: %v", err)
+	}
+	if want := []string{"sage-ci-go-ci.yml", "sage-ci-pr.yml", "sage-ci-stale.yml"}; !slices.Equal(stale, want) {
+		t.Errorf("StaleWorkflows() = %v, want %v", stale, want)
+	}
+}
+
+func TestEject(t *testing.T) {
+	tmpDir := t.TempDir()
+	origOutputDir := outputDir
+	outputDir = tmpDir
+	t.Cleanup(func() { outputDir = origOutputDir })
+
+	cfg := config.Config{GoModules: []string{"."}}
+	if _, err := Eject(cfg, []string{"sage-ci-lua-ci"}); err == nil {
+		t.Error("Eject() of a workflow not generated for the config succeeded, want error")
+	}
+	ejected, err := Eject(cfg, []string{"sage-ci-go-ci"})
+	if err != nil {
+		t.Fatalf("Eject() failed: %v", err)
+	}
+	if want := []string{"sage-ci-go-ci"}; !slices.Equal(ejected, want) {
+		t.Errorf("Eject() = %v, want %v", ejected, want)
+	}
+	content, err := os.ReadFile(filepath.Join(tmpDir, "sage-ci-go-ci.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsGenerated(content) || strings.Contains(string(content), "DO NOT EDIT") {
+		t.Errorf("ejected workflow has a generated header:\n%s", content)
+	}
+	if !strings.Contains(string(content), "make go-test") {
+		t.Errorf("ejected workflow lacks the go-test job:\n%s", content)
+	}
+
+	// Skipped ejected workflows are neither overwritten nor stale.
+	cfg.SkipWorkflows = ejected
+	if err := Sync(cfg); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	synced, err := os.ReadFile(filepath.Join(tmpDir, "sage-ci-go-ci.yml"))
+	if err != nil || string(synced) != string(content) {
+		t.Errorf("Sync overwrote the ejected workflow")
+	}
+	stale, err := StaleWorkflows(cfg)
+	if err != nil {
+		t.Fatalf("StaleWorkflows() failed: %v", err)
+	}
+	if len(stale) > 0 {
+		t.Errorf("StaleWorkflows() = %v with an ejected workflow, want none", stale)
+	}
+}
+
+func TestWorkflowNames(t *testing.T) {
+	names, err := WorkflowNames()
+	if err != nil {
+		t.Fatalf("WorkflowNames() failed: %v", err)
+	}
+	for _, want := range []string{"sage-ci-sync", "sage-ci-release", "sage-ci-go-ci", "sage-ci-python-ci"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("WorkflowNames() = %v, missing %q", names, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package github
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"io/fs"
@@ -21,51 +22,232 @@ type templateData struct {
 	Timestamp   string
 
 	// Module paths
-	GoModules     []string
-	PythonModules []string
-	LuaModules    []string
+	GoModules        []string
+	PythonModules    []string
+	LuaModules       []string
+	TerraformModules []string
+
+	// Go binaries attached to releases
+	GoBinaries []string
+	GoDistDir  string
+
+	// Docs file globs configured
+	HasMarkdown bool
+	HasYAML     bool
+
+	// Lint file globs configured
+	HasDockerfiles      bool
+	HasActionsWorkflows bool
+
+	// Python packages configured
+	HasPythonPackages bool
+
+	// Python typecheckers in use, excluding fully skipped ones
+	PythonTypecheckers []string
+
+	// How workflows run targets, see config.Runner
+	Runner string
+
+	// Workflow triggers
+	ReleaseBranches  []string
+	PushBranches     []string
+	PushTags         []string
+	WorkflowDispatch bool
+	MergeGroup       bool
+	StaleCron        string
+	SyncCron         string
+
+	// Workflow policies
+	Stale   config.StaleConfig
+	PRTitle config.PRTitleConfig
 
 	// Version matrices
 	GoVersions     []string
 	PythonVersions []string
 	OSVersions     []string
 
+	// Per-module test matrices, set when a module overrides the versions or OS matrix
+	GoTestMatrix     []matrixEntry
+	PythonTestMatrix []matrixEntry
+
 	// Skipped targets (fully skipped for all modules)
-	SkipGoTest       bool
-	SkipGoLint       bool
-	SkipGoFormat     bool
-	SkipGoVulncheck  bool
-	SkipPythonTest   bool
-	SkipPythonLint   bool
-	SkipPythonFormat bool
-	SkipPythonMypy   bool
-	SkipLuaFormat    bool
+	SkipGoTest            bool
+	SkipGoLint            bool
+	SkipGoFormat          bool
+	SkipGoVulncheck       bool
+	SkipGoCrossBuild      bool
+	SkipPythonTest        bool
+	SkipPythonLint        bool
+	SkipPythonFormat      bool
+	SkipPythonLockCheck   bool
+	SkipPythonBuild       bool
+	SkipPythonSmoke       bool
+	SkipLuaFormat         bool
+	SkipTerraformFormat   bool
+	SkipTerraformValidate bool
+	SkipTerraformLint     bool
+	SkipMarkdownFormat    bool
+	SkipYamlLint          bool
+	SkipDockerLint        bool
+	SkipActionsLint       bool
+}
+
+// matrixEntry is a GitHub Actions matrix include entry for testing a single module.
+type matrixEntry struct {
+	Module string `json:"module"`
+	OS     string `json:"os"`
+	Go     string `json:"go,omitempty"`
+	Python string `json:"python,omitempty"`
 }
 
-func render(cfg config.Config) error {
+// moduleMatrix returns one matrix entry per module, OS and version if any of the modules
+// not skipped for target overrides its versions or OS matrix, otherwise nil.
+func moduleMatrix(
+	cfg config.Config,
+	target string,
+	modules []string,
+	versions func(config.ModuleOptions) []string,
+	entry func(module, os, version string) matrixEntry,
+) []matrixEntry {
+	var tested []string
+	for _, module := range modules {
+		if !cfg.SkipTargets.ShouldSkip(target, module) {
+			tested = append(tested, module)
+		}
+	}
+	if !cfg.HasModuleMatrix(tested) {
+		return nil
+	}
+	var matrix []matrixEntry
+	for _, module := range tested {
+		opts := cfg.ModuleOptionsFor(module)
+		for _, os := range opts.OSVersions {
+			for _, version := range versions(opts) {
+				matrix = append(matrix, entry(module, os, version))
+			}
+		}
+	}
+	return matrix
+}
+
+// runCommand returns the command running the Makefile target with runner.
+// sage-ci is run at the version required by .sage/go.mod.
+func runCommand(runner config.Runner, target string) string {
+	if runner == config.RunnerSageCi {
+		if target == "update-sage" {
+			// update-sage is a rule of the Makefile generated by Sage, not a sagefile target.
+			return "go -C .sage get go.einride.tech/sage@latest && go -C .sage mod tidy"
+		}
+		return "go -C .sage run github.com/fredrikaverpil/sage-ci/cmd/sage-ci run " + target
+	}
+	return "make " + target
+}
+
+// pushBranches returns the branches CI runs on when pushed to: the default branch and PushBranches.
+func pushBranches(cfg config.Config) []string {
+	branches := []string{cmp.Or(cfg.DefaultBranch, "main")}
+	for _, branch := range cfg.PushBranches {
+		if !slices.Contains(branches, branch) {
+			branches = append(branches, branch)
+		}
+	}
+	return branches
+}
+
+// releaseBranches returns the branches releases are cut from: the default branch, or main and master
+// if it isn't configured.
+func releaseBranches(cfg config.Config) []string {
+	if cfg.DefaultBranch == "" {
+		return []string{"main", "master"}
+	}
+	return []string{cfg.DefaultBranch}
+}
+
+// render writes the workflows generated for cfg to dir.
+func render(cfg config.Config, dir string) error {
 	data := templateData{
-		GeneratedBy:    "sage-ci",
-		Timestamp:      time.Now().Format(time.RFC3339),
-		GoModules:      cfg.GoModules,
-		PythonModules:  cfg.PythonModules,
-		LuaModules:     cfg.LuaModules,
-		GoVersions:     cfg.GoVersions,
-		PythonVersions: cfg.PythonVersions,
-		OSVersions:     cfg.OSVersions,
+		GeneratedBy:         "sage-ci",
+		Timestamp:           time.Now().Format(time.RFC3339),
+		GoModules:           cfg.GoModules,
+		PythonModules:       cfg.PythonModules,
+		LuaModules:          cfg.LuaModules,
+		TerraformModules:    cfg.TerraformModules,
+		GoBinaries:          cfg.GoBinaries,
+		HasPythonPackages:   cfg.HasPythonPackages(),
+		GoDistDir:           cfg.GoDistDir,
+		HasMarkdown:         len(cfg.MarkdownFiles) > 0,
+		HasYAML:             len(cfg.YAMLFiles) > 0,
+		HasDockerfiles:      len(cfg.Dockerfiles) > 0,
+		HasActionsWorkflows: len(cfg.ActionsWorkflows) > 0,
+		GoVersions:          cfg.GoVersions,
+		PythonVersions:      cfg.PythonVersions,
+		OSVersions:          cfg.OSVersions,
+		Runner:              string(cfg.Runner),
+		ReleaseBranches:     releaseBranches(cfg),
+		PushBranches:        pushBranches(cfg),
+		PushTags:            cfg.PushTags,
+		WorkflowDispatch:    cfg.WorkflowDispatch,
+		MergeGroup:          cfg.MergeGroup,
+		StaleCron:           cfg.StaleCron,
+		SyncCron:            cfg.SyncCron,
+		Stale:               cfg.Stale,
+		PRTitle:             cfg.PRTitle,
 
 		// Check if targets are fully skipped
-		SkipGoTest:       cfg.SkipTargets.IsFullySkipped("GoTest", cfg.GoModules),
-		SkipGoLint:       cfg.SkipTargets.IsFullySkipped("GoLint", cfg.GoModules),
-		SkipGoFormat:     cfg.SkipTargets.IsFullySkipped("GoFormat", cfg.GoModules),
-		SkipGoVulncheck:  cfg.SkipTargets.IsFullySkipped("GoVulncheck", cfg.GoModules),
-		SkipPythonTest:   cfg.SkipTargets.IsFullySkipped("PythonTest", cfg.PythonModules),
-		SkipPythonLint:   cfg.SkipTargets.IsFullySkipped("PythonLint", cfg.PythonModules),
-		SkipPythonFormat: cfg.SkipTargets.IsFullySkipped("PythonFormat", cfg.PythonModules),
-		SkipPythonMypy:   cfg.SkipTargets.IsFullySkipped("PythonMypy", cfg.PythonModules),
-		SkipLuaFormat:    cfg.SkipTargets.IsFullySkipped("LuaFormat", cfg.LuaModules),
+		SkipGoTest:            cfg.SkipTargets.IsFullySkipped("GoTest", cfg.GoModules),
+		SkipGoLint:            cfg.SkipTargets.IsFullySkipped("GoLint", cfg.GoModules),
+		SkipGoFormat:          cfg.SkipTargets.IsFullySkipped("GoFormat", cfg.GoModules),
+		SkipGoVulncheck:       cfg.SkipTargets.IsFullySkipped("GoVulncheck", cfg.GoModules),
+		SkipGoCrossBuild:      cfg.SkipTargets.IsFullySkipped("GoCrossBuild", cfg.GoModules),
+		SkipPythonTest:        cfg.SkipTargets.IsFullySkipped("PythonTest", cfg.PythonModules),
+		SkipPythonLint:        cfg.SkipTargets.IsFullySkipped("PythonLint", cfg.PythonModules),
+		SkipPythonFormat:      cfg.SkipTargets.IsFullySkipped("PythonFormat", cfg.PythonModules),
+		SkipPythonLockCheck:   cfg.SkipTargets.IsFullySkipped("PythonLockCheck", cfg.PythonModules),
+		SkipPythonBuild:       cfg.SkipTargets.IsFullySkipped("PythonBuild", cfg.PythonModules),
+		SkipPythonSmoke:       cfg.SkipTargets.IsFullySkipped("PythonSmoke", cfg.PythonModules),
+		SkipLuaFormat:         cfg.SkipTargets.IsFullySkipped("LuaFormat", cfg.LuaModules),
+		SkipTerraformFormat:   cfg.SkipTargets.IsFullySkipped("TerraformFormat", cfg.TerraformModules),
+		SkipTerraformValidate: cfg.SkipTargets.IsFullySkipped("TerraformValidate", cfg.TerraformModules),
+		SkipTerraformLint:     cfg.SkipTargets.IsFullySkipped("TerraformLint", cfg.TerraformModules),
+		SkipMarkdownFormat:    cfg.SkipTargets.IsFullySkipped("MarkdownFormat", nil),
+		SkipYamlLint:          cfg.SkipTargets.IsFullySkipped("YamlLint", nil),
+		SkipDockerLint:        cfg.SkipTargets.IsFullySkipped("DockerLint", nil),
+		SkipActionsLint:       cfg.SkipTargets.IsFullySkipped("ActionsLint", nil),
+	}
+
+	data.GoTestMatrix = moduleMatrix(
+		cfg, "GoTest", cfg.GoModules,
+		func(opts config.ModuleOptions) []string { return opts.GoVersions },
+		func(module, os, version string) matrixEntry {
+			return matrixEntry{Module: module, OS: os, Go: version}
+		},
+	)
+	data.PythonTestMatrix = moduleMatrix(
+		cfg, "PythonTest", cfg.PythonModules,
+		func(opts config.ModuleOptions) []string { return opts.PythonVersions },
+		func(module, os, version string) matrixEntry {
+			return matrixEntry{Module: module, OS: os, Python: version}
+		},
+	)
+
+	for _, checker := range cfg.PythonTypecheckers() {
+		var modules []string
+		for _, module := range cfg.PythonModules {
+			if cfg.PythonOptionsFor(module).Typechecker == checker {
+				modules = append(modules, module)
+			}
+		}
+		target := "Python" + strings.ToUpper(string(checker[:1])) + string(checker[1:])
+		if !cfg.SkipTargets.IsFullySkipped(target, modules) {
+			data.PythonTypecheckers = append(data.PythonTypecheckers, string(checker))
+		}
 	}
 
 	funcMap := template.FuncMap{
+		"join": strings.Join,
+		"run": func(target string) string {
+			return runCommand(cfg.Runner, target)
+		},
 		"toJSON": func(v any) (string, error) {
 			b, err := json.Marshal(v)
 			if err != nil {
@@ -111,40 +293,12 @@ func render(cfg config.Config) error {
 		if err != nil {
 			return fmt.Errorf("get relative path for %s: %w", path, err)
 		}
-		parts := strings.Split(relPath, string(os.PathSeparator))
-
-		// Determine output filename:
-		// - generic/*.yml.tmpl -> sage-ci-*.yml
-		// - <ecosystem>/*.yml.tmpl -> sage-ci-<ecosystem>-*.yml
-		var fileName string
-		if len(parts) == 2 {
-			category := parts[0]
-			name := strings.TrimSuffix(parts[1], ".tmpl")
-
-			if category == "generic" {
-				fileName = fmt.Sprintf("sage-ci-%s", name)
-			} else {
-				fileName = fmt.Sprintf("sage-ci-%s-%s", category, name)
-			}
-		} else {
-			// Fallback
-			fileName = "sage-ci-" + strings.TrimSuffix(filepath.Base(path), ".tmpl")
-		}
-
-		// Check for skip
-		baseName := strings.TrimSuffix(fileName, ".yml")
-		if slices.Contains(cfg.SkipWorkflows, baseName) {
-			return nil
-		}
-
-		// Skip ecosystem-specific workflows if no modules configured
-		if (parts[0] == "go" && len(cfg.GoModules) == 0) ||
-			(parts[0] == "python" && len(cfg.PythonModules) == 0) ||
-			(parts[0] == "lua" && len(cfg.LuaModules) == 0) {
+		fileName := workflowFileName(relPath)
+		if skipReason(cfg, relPath) != "" {
 			return nil
 		}
 
-		outputPath := filepath.Join(outputDir, fileName)
+		outputPath := filepath.Join(dir, fileName)
 
 		// Ensure output dir exists
 		if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
@@ -160,3 +314,121 @@ func render(cfg config.Config) error {
 
 	return err
 }
+
+// workflowFileName returns the output file name for a template path relative to the templates directory:
+//   - generic/*.yml.tmpl -> sage-ci-*.yml
+//   - <ecosystem>/*.yml.tmpl -> sage-ci-<ecosystem>-*.yml
+func workflowFileName(relPath string) string {
+	parts := strings.Split(relPath, string(os.PathSeparator))
+	if len(parts) != 2 {
+		// Fallback
+		return "sage-ci-" + strings.TrimSuffix(filepath.Base(relPath), ".tmpl")
+	}
+	category := parts[0]
+	name := strings.TrimSuffix(parts[1], ".tmpl")
+	if category == "generic" {
+		return fmt.Sprintf("sage-ci-%s", name)
+	}
+	return fmt.Sprintf("sage-ci-%s-%s", category, name)
+}
+
+// skipReason returns why the workflow for a template path relative to the templates directory
+// is not generated for cfg, or an empty string if it is generated.
+func skipReason(cfg config.Config, relPath string) string {
+	// Check for skip
+	if name := strings.TrimSuffix(workflowFileName(relPath), ".yml"); cfg.ShouldSkipWorkflow(name) {
+		return "matched by SkipWorkflows"
+	}
+
+	// Skip ecosystem-specific workflows if no modules configured
+	parts := strings.Split(relPath, string(os.PathSeparator))
+	if (parts[0] == "go" && len(cfg.GoModules) == 0) ||
+		(parts[0] == "python" && len(cfg.PythonModules) == 0) ||
+		(parts[0] == "lua" && len(cfg.LuaModules) == 0) ||
+		(parts[0] == "terraform" && len(cfg.TerraformModules) == 0) ||
+		(parts[0] == "docs" && !cfg.HasDocs()) {
+		return fmt.Sprintf("no %s modules or files configured", parts[0])
+	}
+
+	// Skip the docs workflow if all of its jobs are skipped, a workflow without jobs is invalid
+	if parts[0] == "docs" && !hasJob(cfg, "MarkdownFormat", cfg.MarkdownFiles) &&
+		!hasJob(cfg, "YamlLint", cfg.YAMLFiles) {
+		return "MarkdownFormat and YamlLint are skipped by SkipTargets"
+	}
+
+	// Skip the generic lint workflow if there is nothing to lint
+	if relPath == filepath.Join("generic", "lint.yml.tmpl") &&
+		len(cfg.Dockerfiles) == 0 && len(cfg.ActionsWorkflows) == 0 {
+		return "no Dockerfiles or ActionsWorkflows configured"
+	}
+	if relPath == filepath.Join("generic", "lint.yml.tmpl") && !hasJob(cfg, "DockerLint", cfg.Dockerfiles) &&
+		!hasJob(cfg, "ActionsLint", cfg.ActionsWorkflows) {
+		return "DockerLint and ActionsLint are skipped by SkipTargets"
+	}
+	return ""
+}
+
+// hasJob reports whether a workflow job running target on files is generated for cfg.
+func hasJob(cfg config.Config, target string, files []string) bool {
+	return len(files) > 0 && !cfg.SkipTargets.IsFullySkipped(target, nil)
+}
+
+// Workflow describes a workflow sage-ci can generate.
+type Workflow struct {
+	// Name is the workflow name, without the .yml extension.
+	Name string `json:"name"`
+	// Template is the template path, e.g. "go/ci.yml.tmpl".
+	Template string `json:"template"`
+	// File is the generated file path relative to the repository root, e.g. ".github/workflows/sage-ci-go-ci.yml".
+	File string `json:"file"`
+	// Generated reports whether the workflow is generated for the config.
+	Generated bool `json:"generated"`
+	// SkipReason explains why the workflow is not generated.
+	SkipReason string `json:"skip-reason,omitempty"`
+}
+
+// Workflows returns all workflows sage-ci can generate, and whether they are generated for cfg.
+func Workflows(cfg config.Config) ([]Workflow, error) {
+	var workflows []Workflow
+	err := fs.WalkDir(templatesFS, "templates", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".tmpl") {
+			return nil
+		}
+		relPath, err := filepath.Rel("templates", path)
+		if err != nil {
+			return fmt.Errorf("get relative path for %s: %w", path, err)
+		}
+		reason := skipReason(cfg, relPath)
+		fileName := workflowFileName(relPath)
+		workflows = append(workflows, Workflow{
+			Name:       strings.TrimSuffix(fileName, ".yml"),
+			Template:   filepath.ToSlash(relPath),
+			File:       ".github/workflows/" + fileName,
+			Generated:  reason == "",
+			SkipReason: reason,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list workflow templates: %w", err)
+	}
+	slices.SortFunc(workflows, func(a, b Workflow) int { return strings.Compare(a.Name, b.Name) })
+	return workflows, nil
+}
+
+// WorkflowNames returns the names of all workflows sage-ci can generate, without the .yml extension.
+// These are the values accepted by [config.Config.SkipWorkflows].
+func WorkflowNames() ([]string, error) {
+	workflows, err := Workflows(config.Config{})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(workflows))
+	for _, workflow := range workflows {
+		names = append(names, workflow.Name)
+	}
+	return names, nil
+}